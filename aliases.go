@@ -20,6 +20,29 @@ type Event = api.Event
 type FlushPayload = api.FlushPayload
 type BatchEventsBody = api.BatchEventsBody
 type Feature = api.Feature
+type EvalReason = api.EvalReason
+type EvalDetails = api.EvalDetails
+type OptInStore = api.OptInStore
+type UserDataStore = api.UserDataStore
+type Clock = api.Clock
+type RolloutPoint = api.RolloutPoint
+type TargetRolloutPreview = api.TargetRolloutPreview
+type Explanation = api.Explanation
+type TargetTrace = api.TargetTrace
+type FilterTrace = api.FilterTrace
+
+const (
+	EvalReasonTargetingMatch   = api.EvalReasonTargetingMatch
+	EvalReasonRolloutExcluded  = api.EvalReasonRolloutExcluded
+	EvalReasonNoTargetMatch    = api.EvalReasonNoTargetMatch
+	EvalReasonVariableNotFound = api.EvalReasonVariableNotFound
+	EvalReasonTypeMismatch     = api.EvalReasonTypeMismatch
+	EvalReasonNotInitialized   = api.EvalReasonNotInitialized
+	EvalReasonError            = api.EvalReasonError
+	EvalReasonOverride         = api.EvalReasonOverride
+	EvalReasonHoldout          = api.EvalReasonHoldout
+	EvalReasonLayerExcluded    = api.EvalReasonLayerExcluded
+)
 
 var ErrQueueFull = bucketing.ErrQueueFull
 
@@ -0,0 +1,21 @@
+package api
+
+import "time"
+
+// Clock tells the current time. Replacing it evaluates rollouts and timestamps events as of another time.
+type Clock interface {
+	Now() time.Time
+}
+
+// RolloutPoint is the percentage of users a target's rollout lets through at a point in time.
+type RolloutPoint struct {
+	Time       time.Time `json:"time"`
+	Percentage float64   `json:"percentage"`
+}
+
+// TargetRolloutPreview is the percentage of users a target's rollout lets through over time.
+type TargetRolloutPreview struct {
+	// Unique database id of the target
+	TargetId string         `json:"_target"`
+	Points   []RolloutPoint `json:"points"`
+}
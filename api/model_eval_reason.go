@@ -0,0 +1,44 @@
+package api
+
+// EvalReason describes why a variable evaluated to the value it did.
+type EvalReason string
+
+const (
+	// The user matched a target and was bucketed into one of its variations
+	EvalReasonTargetingMatch EvalReason = "TARGETING_MATCH"
+	// The user matched a target but was excluded by its rollout
+	EvalReasonRolloutExcluded EvalReason = "ROLLOUT_EXCLUDED"
+	// The user did not match any of the targets of the feature owning the variable
+	EvalReasonNoTargetMatch EvalReason = "NO_TARGET_MATCH"
+	// The variable does not exist in the config, or no feature serves it
+	EvalReasonVariableNotFound EvalReason = "VARIABLE_NOT_FOUND"
+	// The variable's value does not match the type of the default value
+	EvalReasonTypeMismatch EvalReason = "TYPE_MISMATCH"
+	// The variable was requested before a config was loaded
+	EvalReasonNotInitialized EvalReason = "NOT_INITIALIZED"
+	// The variable could not be evaluated because of an unexpected error
+	EvalReasonError EvalReason = "ERROR"
+	// The variable was served a value forced by an override set on the client
+	EvalReasonOverride EvalReason = "OVERRIDE"
+	// The user is in a holdout of the experiment owning the variable, and was served its control variation
+	EvalReasonHoldout EvalReason = "HOLDOUT"
+	// The user was assigned to another experiment of the layer of the experiment owning the variable
+	EvalReasonLayerExcluded EvalReason = "LAYER_EXCLUDED"
+)
+
+type EvalDetails struct {
+	// Reason the variable was served its value
+	Reason EvalReason `json:"reason"`
+	// Description of the targeting that served the value, reported by cloud bucketing
+	Details string `json:"details,omitempty"`
+	// Unique database id of the feature owning the variable
+	FeatureId string `json:"_feature,omitempty"`
+	// Unique database id of the target the user matched
+	TargetId string `json:"_target,omitempty"`
+	// Key of the variation the user was bucketed into
+	VariationKey string `json:"variationKey,omitempty"`
+	// Unique database id of the experiment layer that assigned the user to the feature, or excluded them from it
+	LayerId string `json:"_layer,omitempty"`
+	// Unique database id of the holdout that served the user the control variation
+	HoldoutId string `json:"_holdout,omitempty"`
+}
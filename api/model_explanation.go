@@ -0,0 +1,69 @@
+package api
+
+// Explanation traces how a variable was evaluated for a user: the targets of the feature owning the variable,
+// the result of each of their filters, and how the user was bucketed. It is meant to be rendered as JSON.
+type Explanation struct {
+	VariableKey string `json:"variableKey"`
+	// Unique database id and key of the feature owning the variable
+	FeatureId  string `json:"_feature,omitempty"`
+	FeatureKey string `json:"featureKey,omitempty"`
+	// User field or custom data key whose value was hashed to bucket the user
+	BucketingKey   string `json:"bucketingKey,omitempty"`
+	BucketingValue string `json:"bucketingValue,omitempty"`
+	// Targets are traced in the order they are evaluated in
+	Targets []TargetTrace `json:"targets"`
+	// Value served for the variable, or nil if it is defaulted
+	Value interface{} `json:"value,omitempty"`
+	Eval  EvalDetails `json:"eval"`
+	// Error that defaulted the variable, if any
+	Error string `json:"error,omitempty"`
+}
+
+type TargetTrace struct {
+	// Unique database id of the target
+	TargetId string `json:"_target"`
+	// Passed is whether the user is in the audience of the target
+	Passed   bool         `json:"passed"`
+	Audience *FilterTrace `json:"audience,omitempty"`
+	// Selected is set on the first target the user passed, which the user is bucketed with
+	Selected     bool               `json:"selected,omitempty"`
+	Rollout      *RolloutTrace      `json:"rollout,omitempty"`
+	Distribution *DistributionTrace `json:"distribution,omitempty"`
+}
+
+// FilterTrace is the result of a filter, or of an operator and its nested filters.
+type FilterTrace struct {
+	Type       string `json:"type,omitempty"`
+	SubType    string `json:"subType,omitempty"`
+	Operator   string `json:"operator,omitempty"`
+	Comparator string `json:"comparator,omitempty"`
+	DataKey    string `json:"dataKey,omitempty"`
+	// Values of the filter, and the value of the user they were compared with
+	Values    []interface{} `json:"values,omitempty"`
+	UserValue interface{}   `json:"userValue,omitempty"`
+	// AudienceId is the audience whose filters are traced, for the audiences of audience match filters
+	AudienceId string         `json:"_audience,omitempty"`
+	Passed     bool           `json:"passed"`
+	Filters    []*FilterTrace `json:"filters,omitempty"`
+}
+
+type RolloutTrace struct {
+	Type string `json:"type"`
+	// Percentage of users the rollout lets through at the time of evaluation
+	Percentage  float64 `json:"percentage"`
+	RolloutHash float64 `json:"rolloutHash"`
+	Passed      bool    `json:"passed"`
+}
+
+type DistributionTrace struct {
+	BucketingHash float64                      `json:"bucketingHash"`
+	Distribution  []DistributionTraceVariation `json:"distribution"`
+	// Variation picked by the bucketing hash
+	VariationId  string `json:"_variation,omitempty"`
+	VariationKey string `json:"variationKey,omitempty"`
+}
+
+type DistributionTraceVariation struct {
+	VariationId string  `json:"_variation"`
+	Percentage  float64 `json:"percentage"`
+}
@@ -23,4 +23,6 @@ type Feature struct {
 	VariationName string `json:"variationName"`
 	// Evaluation reasoning
 	EvalReason string `json:"evalReason,omitempty"`
+	// User field or custom data key that bucketed the user into the variation
+	BucketingKey string `json:"bucketingKey,omitempty"`
 }
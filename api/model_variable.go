@@ -23,6 +23,8 @@ type Variable struct {
 	DefaultValue interface{} `json:"defaultValue"`
 	// Identifies if variable was returned with the default value
 	IsDefaulted bool `json:"isDefaulted"`
+	// Evaluation reasoning and the feature, target and variation that produced the value
+	Eval EvalDetails `json:"eval"`
 }
 
 type ReadOnlyVariable struct {
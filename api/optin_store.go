@@ -0,0 +1,11 @@
+package api
+
+// OptInStore records the features that users opted in to, for the opt-in filters of local bucketing.
+// Implementations must be safe for concurrent use, and IsOptedIn is called during every evaluation
+// of a feature targeting opted-in users, so it should not block on I/O.
+type OptInStore interface {
+	// IsOptedIn reports whether the user opted in to the feature.
+	IsOptedIn(userId string, featureId string) bool
+	// SetOptIn records that the user opted in to, or out of, the feature.
+	SetOptIn(userId string, featureId string, optedIn bool) error
+}
@@ -0,0 +1,32 @@
+package api
+
+import "reflect"
+
+// UserDataStore keeps the custom data of users between evaluations, so local bucketing can target attributes
+// that were sent in earlier requests, like EdgeDB does for cloud bucketing. Implementations must be safe for
+// concurrent use.
+type UserDataStore interface {
+	// Get returns the stored custom data of the user, or nil if none is stored. The map must not be modified.
+	Get(userId string) (map[string]interface{}, error)
+	// Merge merges customData into the stored custom data of the user, with customData taking precedence, and
+	// returns a copy of the merged data. Reading and storing the data is a single operation, so concurrent merges
+	// of the same user don't lose each other's keys. The merged data is returned even if it could not be stored.
+	Merge(userId string, customData map[string]interface{}) (map[string]interface{}, error)
+}
+
+// MergeCustomData returns a copy of stored with the keys of customData added or replaced, and whether that changed
+// any key of stored.
+func MergeCustomData(stored map[string]interface{}, customData map[string]interface{}) (map[string]interface{}, bool) {
+	merged := make(map[string]interface{}, len(stored)+len(customData))
+	for key, value := range stored {
+		merged[key] = value
+	}
+	changed := false
+	for key, value := range customData {
+		if storedValue, ok := stored[key]; !ok || !reflect.DeepEqual(storedValue, value) {
+			changed = true
+		}
+		merged[key] = value
+	}
+	return merged, changed
+}
@@ -2,6 +2,7 @@ package bucketing
 
 import (
 	"errors"
+	"fmt"
 	"time"
 
 	"github.com/BIwashi/go-server-sdk/v2/api"
@@ -14,12 +15,14 @@ const baseSeed = 1
 
 var ErrMissingVariableForVariation = errors.New("Config missing variable for variation")
 var ErrMissingFeature = errors.New("Config missing feature for variable")
+var ErrMissingFeatureForKey = errors.New("Config missing feature for key")
 var ErrMissingVariable = errors.New("Config missing variable")
 var ErrMissingVariation = errors.New("Config missing variation")
 var ErrFailedToDecideVariation = errors.New("Failed to decide target variation")
 var ErrUserRollout = errors.New("User does not qualify for feature rollout")
 var ErrUserDoesNotQualifyForTargets = errors.New("User does not qualify for any targets for feature")
 var ErrInvalidVariableType = errors.New("Invalid variable type")
+var ErrConfigNotInitialized = errors.New("config not initialized")
 
 type boundedHash struct {
 	RolloutHash   float64 `json:"rolloutHash"`
@@ -51,29 +54,16 @@ func getCurrentRolloutPercentage(rollout Rollout, currentDate time.Time) float64
 		return 0
 	}
 
+	// The current stage is the last stage that has started, the next stage is the first that has not
 	var stages = rollout.Stages
-	var currentStages []RolloutStage
-	var nextStages []RolloutStage
-
-	for _, stage := range stages {
-		if stage.Date.Before(currentDateTime) {
-			currentStages = append(currentStages, stage)
-		} else {
-			nextStages = append(nextStages, stage)
-		}
-	}
-
 	var _currentStage *RolloutStage
 	var nextStage *RolloutStage
-	if len(currentStages) == 0 {
-		_currentStage = nil
-	} else {
-		_currentStage = &currentStages[len(currentStages)-1]
-	}
-	if len(nextStages) == 0 {
-		nextStage = nil
-	} else {
-		nextStage = &nextStages[0]
+	for i := range stages {
+		if stages[i].Date.Before(currentDateTime) {
+			_currentStage = &stages[i]
+		} else if nextStage == nil {
+			nextStage = &stages[i]
+		}
 	}
 	currentStage := _currentStage
 	if _currentStage == nil && startDateTime.Before(currentDateTime) {
@@ -92,51 +82,84 @@ func getCurrentRolloutPercentage(rollout Rollout, currentDate time.Time) float64
 
 	currentDatePercentage := float64(currentDateTime.Sub(currentStage.Date).Milliseconds()) /
 		float64(nextStage.Date.Sub(currentStage.Date).Milliseconds())
-	if currentDatePercentage == 0 {
-		return 0
-	}
-	return (currentStage.Percentage + (nextStage.Percentage - currentStage.Percentage)) * currentDatePercentage
+	return currentStage.Percentage + (nextStage.Percentage-currentStage.Percentage)*currentDatePercentage
 }
 
-func doesUserPassRollout(rollout Rollout, boundedHash float64) bool {
-	var rolloutPercentage = getCurrentRolloutPercentage(rollout, time.Now())
+func doesUserPassRollout(rollout Rollout, boundedHash float64, now time.Time) bool {
+	var rolloutPercentage = getCurrentRolloutPercentage(rollout, now)
 	return rolloutPercentage != 0 && (boundedHash <= rolloutPercentage)
 }
 
-func evaluateSegmentationForFeature(config *configBody, feature *ConfigFeature, user api.PopulatedUser, clientCustomData map[string]interface{}) *Target {
+func evaluateSegmentationForFeature(config *configBody, feature *ConfigFeature, user api.PopulatedUser, inputs evaluationInputs) *Target {
+	ctx := acquireFilterContext(&user, inputs.clientCustomData)
+	defer releaseFilterContext(ctx)
+	ctx.optIns = inputs.optIns
+	ctx.featureId = feature.Id
 	for _, target := range feature.Configuration.Targets {
-		operator := target.Audience.Filters
-		if operator.Evaluate(config.Audiences, user, clientCustomData) {
+		if config.targetFilter(target)(ctx) {
 			return target
 		}
 	}
 	return nil
 }
 
+// evaluationInputs is the state of an engine that evaluations read, besides the config.
+type evaluationInputs struct {
+	clientCustomData map[string]interface{}
+	optIns           api.OptInStore
+	userData         api.UserDataStore
+	// bucketingKey buckets users into targets that don't name a bucketing key, instead of the user_id
+	bucketingKey string
+	// clock evaluates rollouts, or time.Now when nil
+	clock api.Clock
+}
+
+func (inputs evaluationInputs) now() time.Time {
+	if inputs.clock == nil {
+		return time.Now()
+	}
+	return inputs.clock.Now()
+}
+
 type targetAndHashes struct {
-	Target Target
-	Hashes boundedHash
+	Target       Target
+	Hashes       boundedHash
+	BucketingKey string
+	Decision     experimentDecision
 }
 
-func doesUserQualifyForFeature(config *configBody, feature *ConfigFeature, user api.PopulatedUser, clientCustomData map[string]interface{}) (targetAndHashes, error) {
-	target := evaluateSegmentationForFeature(config, feature, user, clientCustomData)
+func doesUserQualifyForFeature(config *configBody, feature *ConfigFeature, user api.PopulatedUser, inputs evaluationInputs) (targetAndHashes, error) {
+	target := evaluateSegmentationForFeature(config, feature, user, inputs)
 	if target == nil {
 		return targetAndHashes{}, ErrUserDoesNotQualifyForTargets
 	}
 
-	boundedHashes := generateBoundedHashes(user.UserId, target.Id)
+	bucketingKey, bucketingValue := inputs.bucketingValue(&user, target)
+	decision, err := config.decideExperiment(feature, &user, inputs)
+	if err != nil || decision.HoldoutId != "" {
+		// Held out users skip the rollout, as they are served the control variation
+		return targetAndHashes{Target: *target, BucketingKey: bucketingKey, Decision: decision}, err
+	}
+
+	boundedHashes := generateBoundedHashes(bucketingValue, target.Id)
 	rolloutHash := boundedHashes.RolloutHash
 
-	if target.Rollout != nil && !doesUserPassRollout(*target.Rollout, rolloutHash) {
-		return targetAndHashes{}, ErrUserRollout
+	if target.Rollout != nil && !doesUserPassRollout(*target.Rollout, rolloutHash, inputs.now()) {
+		// Keep the target so callers can report which target's rollout excluded the user
+		return targetAndHashes{Target: *target, BucketingKey: bucketingKey, Decision: decision}, ErrUserRollout
 	}
 	return targetAndHashes{
-		Target: *target,
-		Hashes: boundedHashes,
+		Target:       *target,
+		Hashes:       boundedHashes,
+		BucketingKey: bucketingKey,
+		Decision:     decision,
 	}, nil
 }
 
 func bucketUserForVariation(feature *ConfigFeature, hashes targetAndHashes) (*Variation, error) {
+	if hashes.Decision.HoldoutId != "" {
+		return feature.controlVariation()
+	}
 	variationId, err := hashes.Target.DecideTargetVariation(hashes.Hashes.BucketingHash)
 	if err != nil {
 		return nil, err
@@ -154,13 +177,18 @@ func GenerateBucketedConfig(sdkKey string, user api.PopulatedUser, clientCustomD
 	if err != nil {
 		return nil, err
 	}
+	return generateBucketedConfig(config, user, evaluationInputs{clientCustomData: clientCustomData})
+}
+
+func generateBucketedConfig(config *configBody, user api.PopulatedUser, inputs evaluationInputs) (*api.BucketedUserConfig, error) {
+	user = inputs.withStoredUserData(config, user, false)
 	variableMap := make(map[string]api.ReadOnlyVariable)
 	featureKeyMap := make(map[string]api.Feature)
 	featureVariationMap := make(map[string]string)
 	variableVariationMap := make(map[string]api.FeatureVariation)
 
 	for _, feature := range config.Features {
-		thash, err := doesUserQualifyForFeature(config, feature, user, clientCustomData)
+		thash, err := doesUserQualifyForFeature(config, feature, user, inputs)
 		if err != nil {
 			continue
 		}
@@ -169,14 +197,19 @@ func GenerateBucketedConfig(sdkKey string, user api.PopulatedUser, clientCustomD
 		if err != nil {
 			return nil, err
 		}
-		featureKeyMap[feature.Key] = api.Feature{
+		bucketedFeature := api.Feature{
 			Id:            feature.Id,
 			Type_:         feature.Type,
 			Key:           feature.Key,
 			Variation:     variation.Id,
 			VariationKey:  variation.Key,
 			VariationName: variation.Name,
+			BucketingKey:  thash.BucketingKey,
 		}
+		if thash.Decision.HoldoutId != "" {
+			bucketedFeature.EvalReason = string(api.EvalReasonHoldout)
+		}
+		featureKeyMap[feature.Key] = bucketedFeature
 		featureVariationMap[feature.Id] = variation.Id
 
 		for _, variationVar := range variation.Variables {
@@ -211,30 +244,155 @@ func GenerateBucketedConfig(sdkKey string, user api.PopulatedUser, clientCustomD
 	}, nil
 }
 
+// VariableEvaluation is the result of bucketing a user for a single variable, along with the reason for the result.
+type VariableEvaluation struct {
+	Type        string
+	Value       any
+	Reason      api.EvalReason
+	FeatureId   string
+	TargetId    string
+	VariationId string
+	// Key of the variation the user was bucketed into
+	VariationKey string
+	// Layer that assigned the user to the feature, or excluded them from it
+	LayerId string
+	// Holdout that served the user the control variation of the feature
+	HoldoutId string
+}
+
+func (v VariableEvaluation) experimentDecision() experimentDecision {
+	return experimentDecision{LayerId: v.LayerId, HoldoutId: v.HoldoutId}
+}
+
+// EvalDetails returns the public evaluation details for the result.
+func (v VariableEvaluation) EvalDetails() api.EvalDetails {
+	return api.EvalDetails{
+		Reason:       v.Reason,
+		FeatureId:    v.FeatureId,
+		TargetId:     v.TargetId,
+		VariationKey: v.VariationKey,
+		LayerId:      v.LayerId,
+		HoldoutId:    v.HoldoutId,
+	}
+}
+
 func VariableForUser(sdkKey string, user api.PopulatedUser, variableKey string, expectedVariableType string, eventQueue *EventQueue, clientCustomData map[string]interface{}) (variableType string, variableValue any, err error) {
-	variableType, variableValue, featureId, variationId, err := generateBucketedVariableForUser(sdkKey, user, variableKey, clientCustomData)
+	evaluation, err := EvaluateVariableForUser(sdkKey, user, variableKey, expectedVariableType, eventQueue, clientCustomData)
+	return evaluation.Type, evaluation.Value, err
+}
+
+// EvaluateVariableForUser behaves like VariableForUser, but also reports why the variable was or was not served.
+// The Reason is always set, even when an error is returned. A nil eventQueue evaluates without queuing events.
+func EvaluateVariableForUser(sdkKey string, user api.PopulatedUser, variableKey string, expectedVariableType string, eventQueue *EventQueue, clientCustomData map[string]interface{}) (VariableEvaluation, error) {
+	config, _ := getConfig(sdkKey)
+	return evaluateVariable(config, user, variableKey, expectedVariableType, nil, eventQueue, evaluationInputs{clientCustomData: clientCustomData})
+}
+
+// ValueCheck rejects variable values of the expected type that the caller still can't use, such as numbers that
+// are not integers.
+type ValueCheck func(value interface{}) error
+
+// evaluateVariable evaluates the variable against config, which is nil when no config has been set yet.
+func evaluateVariable(config *configBody, user api.PopulatedUser, variableKey string, expectedVariableType string, check ValueCheck, eventQueue *EventQueue, inputs evaluationInputs) (VariableEvaluation, error) {
+	if config == nil {
+		return completeVariableEvaluation(VariableEvaluation{}, ErrConfigNotInitialized, variableKey, expectedVariableType, nil, eventQueue)
+	}
+	user = inputs.withStoredUserData(config, user, eventQueue != nil)
+	evaluation, err := bucketVariableInConfig(config, user, variableKey, inputs, nil)
+	return completeVariableEvaluation(evaluation, err, variableKey, expectedVariableType, check, eventQueue)
+}
+
+// EvaluateVariablesForUser evaluates several variables for the user against a single config snapshot.
+// expectedVariableTypes maps each requested variable key to its expected type, or "" to skip the type check.
+// Each feature owning a requested variable is segmented and bucketed at most once. A variable was served when
+// its Reason is api.EvalReasonTargetingMatch or api.EvalReasonHoldout; otherwise the Reason explains why it should
+// be defaulted.
+func EvaluateVariablesForUser(sdkKey string, user api.PopulatedUser, expectedVariableTypes map[string]string, eventQueue *EventQueue, clientCustomData map[string]interface{}) (map[string]VariableEvaluation, error) {
+	config, err := getConfig(sdkKey)
 	if err != nil {
-		eventErr := eventQueue.QueueVariableEvaluatedEvent(variableKey, "", "", true)
-		if eventErr != nil {
-			util.Warnf("Failed to queue variable defaulted event: %s", eventErr)
+		return nil, err
+	}
+	return evaluateVariables(config, user, expectedVariableTypes, eventQueue, evaluationInputs{clientCustomData: clientCustomData}), nil
+}
+
+func evaluateVariables(config *configBody, user api.PopulatedUser, expectedVariableTypes map[string]string, eventQueue *EventQueue, inputs evaluationInputs) map[string]VariableEvaluation {
+	user = inputs.withStoredUserData(config, user, eventQueue != nil)
+	featureResults := make(map[string]featureBucketingResult)
+	evaluations := make(map[string]VariableEvaluation, len(expectedVariableTypes))
+	for variableKey, expectedVariableType := range expectedVariableTypes {
+		evaluation, err := bucketVariableInConfig(config, user, variableKey, inputs, featureResults)
+		evaluations[variableKey], _ = completeVariableEvaluation(evaluation, err, variableKey, expectedVariableType, nil, eventQueue)
+	}
+	return evaluations
+}
+
+// completeVariableEvaluation checks the bucketed variable against the expected type and the check, if any, sets
+// the reason for the result, and queues the matching aggregate evaluation event.
+func completeVariableEvaluation(evaluation VariableEvaluation, err error, variableKey string, expectedVariableType string, check ValueCheck, eventQueue *EventQueue) (VariableEvaluation, error) {
+	decided := evaluation.LayerId != "" || evaluation.HoldoutId != ""
+	if err != nil {
+		if eventQueue != nil && decided {
+			eventErr := eventQueue.queueVariableDecisionEvent(variableKey, evaluation.FeatureId, "", evaluation.experimentDecision(), true)
+			if eventErr != nil {
+				util.Warnf("Failed to queue variable defaulted event: %s", eventErr)
+			}
+		} else if eventQueue != nil {
+			eventErr := eventQueue.QueueVariableEvaluatedEvent(variableKey, "", "", true)
+			if eventErr != nil {
+				util.Warnf("Failed to queue variable defaulted event: %s", eventErr)
+			}
 		}
-		return "", nil, err
+		evaluation.Type = ""
+		evaluation.Value = nil
+		evaluation.Reason = evalReasonForError(err)
+		return evaluation, err
 	}
 
 	var variableDefaulted bool
-	if !isVariableTypeValid(variableType, expectedVariableType) && expectedVariableType != "" {
+	if !isVariableTypeValid(evaluation.Type, expectedVariableType) && expectedVariableType != "" {
 		err = ErrInvalidVariableType
+		evaluation.Reason = api.EvalReasonTypeMismatch
 		variableDefaulted = true
+	} else if check != nil {
+		if checkErr := check(evaluation.Value); checkErr != nil {
+			err = fmt.Errorf("%w: %v", ErrInvalidVariableType, checkErr)
+			evaluation.Reason = api.EvalReasonTypeMismatch
+			variableDefaulted = true
+		}
 	}
 
-	if !eventQueue.options.DisableAutomaticEventLogging {
-		eventErr := eventQueue.QueueVariableEvaluatedEvent(variableKey, featureId, variationId, variableDefaulted)
+	if eventQueue != nil && !eventQueue.options.DisableAutomaticEventLogging {
+		var eventErr error
+		if decided {
+			eventErr = eventQueue.queueVariableDecisionEvent(variableKey, evaluation.FeatureId, evaluation.VariationId, evaluation.experimentDecision(), variableDefaulted)
+		} else {
+			eventErr = eventQueue.QueueVariableEvaluatedEvent(variableKey, evaluation.FeatureId, evaluation.VariationId, variableDefaulted)
+		}
 		if eventErr != nil {
 			util.Warnf("Failed to queue variable evaluated event: %s", eventErr)
 		}
 	}
 
-	return
+	return evaluation, err
+}
+
+func evalReasonForError(err error) api.EvalReason {
+	switch {
+	case errors.Is(err, ErrConfigNotInitialized):
+		return api.EvalReasonNotInitialized
+	case errors.Is(err, ErrMissingVariable), errors.Is(err, ErrMissingFeature):
+		return api.EvalReasonVariableNotFound
+	case errors.Is(err, ErrUserDoesNotQualifyForTargets):
+		return api.EvalReasonNoTargetMatch
+	case errors.Is(err, ErrUserRollout):
+		return api.EvalReasonRolloutExcluded
+	case errors.Is(err, ErrUserLayerExcluded):
+		return api.EvalReasonLayerExcluded
+	case errors.Is(err, ErrInvalidVariableType):
+		return api.EvalReasonTypeMismatch
+	default:
+		return api.EvalReasonError
+	}
 }
 
 func isVariableTypeValid(variableType string, expectedVariableType string) bool {
@@ -250,34 +408,71 @@ func isVariableTypeValid(variableType string, expectedVariableType string) bool
 	return true
 }
 
-func generateBucketedVariableForUser(sdkKey string, user api.PopulatedUser, key string, clientCustomData map[string]interface{}) (variableType string, variableValue any, featureId string, variationId string, err error) {
+// generateBucketedVariableForUser buckets the user for the feature owning the variable. On error, the returned
+// evaluation still carries the feature and target that were reached before bucketing failed.
+func generateBucketedVariableForUser(sdkKey string, user api.PopulatedUser, key string, clientCustomData map[string]interface{}) (VariableEvaluation, error) {
 	config, err := getConfig(sdkKey)
 	if err != nil {
-		return "", nil, "", "", err
+		return VariableEvaluation{}, err
+	}
+	return bucketVariableInConfig(config, user, key, evaluationInputs{clientCustomData: clientCustomData}, nil)
+}
+
+type featureBucketingResult struct {
+	targetId  string
+	decision  experimentDecision
+	variation *Variation
+	err       error
+}
+
+func bucketUserForFeature(config *configBody, feature *ConfigFeature, user api.PopulatedUser, inputs evaluationInputs) featureBucketingResult {
+	th, err := doesUserQualifyForFeature(config, feature, user, inputs)
+	if err != nil {
+		return featureBucketingResult{targetId: th.Target.Id, decision: th.Decision, err: err}
 	}
+	variation, err := bucketUserForVariation(feature, th)
+	return featureBucketingResult{targetId: th.Target.Id, decision: th.Decision, variation: variation, err: err}
+}
+
+// bucketVariableInConfig buckets the user for the feature owning the variable in the given config.
+// If featureResults is non-nil, it caches the result for each feature so variables sharing a feature
+// only evaluate it once.
+func bucketVariableInConfig(config *configBody, user api.PopulatedUser, key string, inputs evaluationInputs, featureResults map[string]featureBucketingResult) (evaluation VariableEvaluation, err error) {
 	variable := config.GetVariableForKey(key)
 	if variable == nil {
-		err = ErrMissingVariable
-		return "", nil, "", "", err
+		return evaluation, ErrMissingVariable
 	}
 	featForVariable := config.GetFeatureForVariableId(variable.Id)
 	if featForVariable == nil {
-		err = ErrMissingFeature
-		return "", nil, "", "", err
+		return evaluation, ErrMissingFeature
 	}
+	evaluation.FeatureId = featForVariable.Id
 
-	th, err := doesUserQualifyForFeature(config, featForVariable, user, clientCustomData)
-	if err != nil {
-		return "", nil, "", "", err
+	result, ok := featureResults[featForVariable.Id]
+	if !ok {
+		result = bucketUserForFeature(config, featForVariable, user, inputs)
+		if featureResults != nil {
+			featureResults[featForVariable.Id] = result
+		}
 	}
-	variation, err := bucketUserForVariation(featForVariable, th)
-	if err != nil {
-		return "", nil, "", "", err
+	evaluation.TargetId = result.targetId
+	evaluation.LayerId = result.decision.LayerId
+	evaluation.HoldoutId = result.decision.HoldoutId
+	if result.err != nil {
+		return evaluation, result.err
 	}
+	variation := result.variation
+	evaluation.VariationId = variation.Id
+	evaluation.VariationKey = variation.Key
 	variationVariable := variation.GetVariableById(variable.Id)
 	if variationVariable == nil {
-		err = ErrMissingVariableForVariation
-		return "", nil, "", "", err
+		return evaluation, ErrMissingVariableForVariation
+	}
+	evaluation.Type = variable.Type
+	evaluation.Value = variationVariable.Value
+	evaluation.Reason = api.EvalReasonTargetingMatch
+	if evaluation.HoldoutId != "" {
+		evaluation.Reason = api.EvalReasonHoldout
 	}
-	return variable.Type, variationVariable.Value, featForVariable.Id, variation.Id, nil
+	return evaluation, nil
 }
@@ -0,0 +1,74 @@
+package bucketing
+
+import (
+	"strconv"
+
+	"github.com/BIwashi/go-server-sdk/v2/api"
+)
+
+// bucketingValue returns the key that buckets the user into the target's rollout and distribution, and the value
+// of that key that is hashed. The key of the target takes precedence over the key the engine is configured with.
+// Keys name a user field, like "email", or else a custom data key. Users without a value for the key are bucketed
+// by their user_id.
+func (inputs evaluationInputs) bucketingValue(user *api.PopulatedUser, target *Target) (key string, value string) {
+	key = target.BucketingKey
+	if key == "" {
+		key = inputs.bucketingKey
+	}
+	if key != "" && key != SubTypeUserID {
+		if value = userBucketingValue(user, key, inputs.clientCustomData); value != "" {
+			return key, value
+		}
+	}
+	return SubTypeUserID, user.UserId
+}
+
+func userBucketingValue(user *api.PopulatedUser, key string, clientCustomData map[string]interface{}) string {
+	switch key {
+	case SubTypeEmail:
+		return user.Email
+	case SubTypeName:
+		return user.Name
+	case SubTypeLanguage:
+		return user.Language
+	case SubTypeCountry:
+		return user.Country
+	case SubTypeAppVersion:
+		return user.AppVersion
+	case SubTypeAppBuild:
+		return user.AppBuild
+	case SubTypeDeviceModel:
+		return user.User.DeviceModel
+	case SubTypeIP:
+		return user.IP
+	case SubTypePlatform, SubTypePlatformVersion:
+		if user.PlatformData == nil {
+			return ""
+		}
+		if key == SubTypePlatform {
+			return user.Platform
+		}
+		return user.PlatformVersion
+	}
+
+	value, ok := user.PrivateCustomData[key]
+	if !ok {
+		value, ok = user.CustomData[key]
+	}
+	if !ok {
+		value = clientCustomData[key]
+	}
+	switch value := value.(type) {
+	case string:
+		return value
+	case float64:
+		return strconv.FormatFloat(value, 'f', -1, 64)
+	case int:
+		return strconv.Itoa(value)
+	case int64:
+		return strconv.FormatInt(value, 10)
+	case bool:
+		return strconv.FormatBool(value)
+	}
+	return ""
+}
@@ -33,14 +33,14 @@ func TestBucketingFirstMatchingTarget(t *testing.T) {
 		Country: "Canada",
 	}.GetPopulatedUser(&api.PlatformData{})
 
-	target, err := doesUserQualifyForFeature(config, feature, user, nil)
+	target, err := doesUserQualifyForFeature(config, feature, user, evaluationInputs{})
 	require.NoError(t, err)
 
 	// should match target 2
 	require.Equal(t, target.Target.Id, "61536f468fd67f0091982533")
 
 	user.Email = "test@email.com"
-	target, err = doesUserQualifyForFeature(config, feature, user, nil)
+	target, err = doesUserQualifyForFeature(config, feature, user, evaluationInputs{})
 	require.NoError(t, err)
 
 	// should match target 1
@@ -71,12 +71,12 @@ func TestBucketing_RolloutGatesUser(t *testing.T) {
 		},
 	}
 
-	_, err = doesUserQualifyForFeature(config, feature, user, nil)
+	_, err = doesUserQualifyForFeature(config, feature, user, evaluationInputs{})
 	require.Error(t, err)
 	require.Equal(t, ErrUserRollout, err)
 
 	user.UserId = "pass_rollout"
-	target, err := doesUserQualifyForFeature(config, feature, user, nil)
+	target, err := doesUserQualifyForFeature(config, feature, user, evaluationInputs{})
 	require.NoError(t, err)
 	require.Equal(t, "61536f468fd67f0091982533", target.Target.Id)
 }
@@ -250,30 +250,30 @@ func TestRollout_Gradual(t *testing.T) {
 			},
 		},
 	}
-	if !doesUserPassRollout(rollout, 0.35) {
+	if !doesUserPassRollout(rollout, 0.35, time.Now()) {
 		t.Errorf("User should pass rollout - 0.35")
 	}
-	if doesUserPassRollout(rollout, 0.85) {
+	if doesUserPassRollout(rollout, 0.85, time.Now()) {
 		t.Errorf("User should not pass rollout - 0.85")
 	}
-	if !doesUserPassRollout(rollout, 0.2) {
+	if !doesUserPassRollout(rollout, 0.2, time.Now()) {
 		t.Errorf("User should pass rollout - 0.2")
 	}
-	if doesUserPassRollout(rollout, 0.75) {
+	if doesUserPassRollout(rollout, 0.75, time.Now()) {
 		t.Errorf("User should not pass rollout - 0.75")
 	}
 	t.Log("Changing rollout percentage to 0.8")
 	rollout.Stages[0].Percentage = 0.8
 
-	if doesUserPassRollout(rollout, 0.51) {
+	if doesUserPassRollout(rollout, 0.51, time.Now()) {
 		t.Error("User should not pass rollout - 0.51")
 	}
 
-	if doesUserPassRollout(rollout, 0.95) {
+	if doesUserPassRollout(rollout, 0.95, time.Now()) {
 		t.Error("User should not pass rollout - 0.95")
 	}
 
-	if !doesUserPassRollout(rollout, 0.35) {
+	if !doesUserPassRollout(rollout, 0.35, time.Now()) {
 		t.Error("User should pass rollout - 0.35")
 	}
 }
@@ -292,19 +292,19 @@ func TestRollout_Gradual_WithStartDateFuture(t *testing.T) {
 		},
 	}
 
-	if doesUserPassRollout(rollout, 0) {
+	if doesUserPassRollout(rollout, 0, time.Now()) {
 		t.Error("User should not pass rollout - 0")
 	}
-	if doesUserPassRollout(rollout, 0.25) {
+	if doesUserPassRollout(rollout, 0.25, time.Now()) {
 		t.Error("User should not pass rollout - 0.25")
 	}
-	if doesUserPassRollout(rollout, 0.5) {
+	if doesUserPassRollout(rollout, 0.5, time.Now()) {
 		t.Error("User should not pass rollout - 0.5")
 	}
-	if doesUserPassRollout(rollout, 0.75) {
+	if doesUserPassRollout(rollout, 0.75, time.Now()) {
 		t.Error("User should not pass rollout - 0.75")
 	}
-	if doesUserPassRollout(rollout, 1) {
+	if doesUserPassRollout(rollout, 1, time.Now()) {
 		t.Error("User should not pass rollout - 1")
 	}
 }
@@ -317,19 +317,19 @@ func TestRollout_Gradual_WithStartDate_NoEnd(t *testing.T) {
 		Stages:          []RolloutStage{},
 	}
 
-	if !doesUserPassRollout(rollout, 0) {
+	if !doesUserPassRollout(rollout, 0, time.Now()) {
 		t.Error("User should pass rollout - 0")
 	}
-	if !doesUserPassRollout(rollout, 0.25) {
+	if !doesUserPassRollout(rollout, 0.25, time.Now()) {
 		t.Error("User should pass rollout - 0.25")
 	}
-	if !doesUserPassRollout(rollout, 0.5) {
+	if !doesUserPassRollout(rollout, 0.5, time.Now()) {
 		t.Error("User should pass rollout - 0.5")
 	}
-	if !doesUserPassRollout(rollout, 0.75) {
+	if !doesUserPassRollout(rollout, 0.75, time.Now()) {
 		t.Error("User should pass rollout - 0.75")
 	}
-	if !doesUserPassRollout(rollout, 1) {
+	if !doesUserPassRollout(rollout, 1, time.Now()) {
 		t.Error("User should pass rollout - 1")
 	}
 }
@@ -342,19 +342,19 @@ func TestRollout_Gradual_WithStartDate_NoEnd_Future(t *testing.T) {
 		Stages:          []RolloutStage{},
 	}
 
-	if doesUserPassRollout(rollout, 0) {
+	if doesUserPassRollout(rollout, 0, time.Now()) {
 		t.Error("User should not pass rollout - 0")
 	}
-	if doesUserPassRollout(rollout, 0.25) {
+	if doesUserPassRollout(rollout, 0.25, time.Now()) {
 		t.Error("User should not pass rollout - 0.25")
 	}
-	if doesUserPassRollout(rollout, 0.5) {
+	if doesUserPassRollout(rollout, 0.5, time.Now()) {
 		t.Error("User should not pass rollout - 0.5")
 	}
-	if doesUserPassRollout(rollout, 0.75) {
+	if doesUserPassRollout(rollout, 0.75, time.Now()) {
 		t.Error("User should not pass rollout - 0.75")
 	}
-	if doesUserPassRollout(rollout, 1) {
+	if doesUserPassRollout(rollout, 1, time.Now()) {
 		t.Error("User should not pass rollout - 1")
 	}
 }
@@ -365,19 +365,19 @@ func TestRollout_Schedule_Valid(t *testing.T) {
 		StartDate: time.Now().Add(time.Minute * -1),
 	}
 
-	if !doesUserPassRollout(rollout, 0) {
+	if !doesUserPassRollout(rollout, 0, time.Now()) {
 		t.Error("User should pass rollout - 0")
 	}
-	if !doesUserPassRollout(rollout, 0.25) {
+	if !doesUserPassRollout(rollout, 0.25, time.Now()) {
 		t.Error("User should pass rollout - 0.25")
 	}
-	if !doesUserPassRollout(rollout, 0.5) {
+	if !doesUserPassRollout(rollout, 0.5, time.Now()) {
 		t.Error("User should pass rollout - 0.5")
 	}
-	if !doesUserPassRollout(rollout, 0.75) {
+	if !doesUserPassRollout(rollout, 0.75, time.Now()) {
 		t.Error("User should pass rollout - 0.75")
 	}
-	if !doesUserPassRollout(rollout, 1) {
+	if !doesUserPassRollout(rollout, 1, time.Now()) {
 		t.Error("User should pass rollout - 1")
 	}
 }
@@ -389,19 +389,19 @@ func TestRollout_Schedule_Future(t *testing.T) {
 		StartDate: time.Now().Add(time.Minute * 1),
 	}
 
-	if doesUserPassRollout(rollout, 0) {
+	if doesUserPassRollout(rollout, 0, time.Now()) {
 		t.Error("User should not pass rollout - 0")
 	}
-	if doesUserPassRollout(rollout, 0.25) {
+	if doesUserPassRollout(rollout, 0.25, time.Now()) {
 		t.Error("User should not pass rollout - 0.25")
 	}
-	if doesUserPassRollout(rollout, 0.5) {
+	if doesUserPassRollout(rollout, 0.5, time.Now()) {
 		t.Error("User should not pass rollout - 0.5")
 	}
-	if doesUserPassRollout(rollout, 0.75) {
+	if doesUserPassRollout(rollout, 0.75, time.Now()) {
 		t.Error("User should not pass rollout - 0.75")
 	}
-	if doesUserPassRollout(rollout, 1) {
+	if doesUserPassRollout(rollout, 1, time.Now()) {
 		t.Error("User should not pass rollout - 1")
 	}
 }
@@ -428,29 +428,29 @@ func TestRollout_Stepped_Valid(t *testing.T) {
 		},
 	}
 
-	if !doesUserPassRollout(rollout, 0) {
+	if !doesUserPassRollout(rollout, 0, time.Now()) {
 		t.Error("User should pass rollout - 0")
 	}
-	if !doesUserPassRollout(rollout, 0.25) {
+	if !doesUserPassRollout(rollout, 0.25, time.Now()) {
 		t.Error("User should pass rollout - 0.25")
 	}
-	if !doesUserPassRollout(rollout, 0.4) {
+	if !doesUserPassRollout(rollout, 0.4, time.Now()) {
 		t.Error("User should pass rollout - 0.4")
 	}
-	if doesUserPassRollout(rollout, 0.6) {
+	if doesUserPassRollout(rollout, 0.6, time.Now()) {
 		t.Error("User should not pass rollout - 0.6")
 	}
-	if doesUserPassRollout(rollout, 0.9) {
+	if doesUserPassRollout(rollout, 0.9, time.Now()) {
 		t.Error("User should not pass rollout - 0.9")
 	}
 }
 
 func TestRollout_Stepped_Error(t *testing.T) {
 	rollout := Rollout{}
-	if doesUserPassRollout(rollout, 0) {
+	if doesUserPassRollout(rollout, 0, time.Now()) {
 		t.Error("User should not pass rollout - empty")
 	}
-	if doesUserPassRollout(rollout, 1) {
+	if doesUserPassRollout(rollout, 1, time.Now()) {
 		t.Error("User should not pass rollout - empty")
 	}
 }
@@ -472,7 +472,7 @@ func TestClientData(t *testing.T) {
 	// Ensure bucketed config has a feature variation map that's empty
 	bucketedUserConfig, err := GenerateBucketedConfig("test", user, nil)
 	require.NoError(t, err)
-	_, _, _, _, err = generateBucketedVariableForUser("test", user, "num-var", nil)
+	_, err = generateBucketedVariableForUser("test", user, "num-var", nil)
 	require.ErrorContainsf(t, err, "does not qualify", "does not qualify")
 	require.Equal(t, map[string]string{}, bucketedUserConfig.FeatureVariationMap)
 
@@ -489,12 +489,12 @@ func TestClientData(t *testing.T) {
 		"614ef6aa473928459060721a": "615357cf7e9ebdca58446ed0",
 		"614ef6aa475928459060721a": "615382338424cb11646d7667",
 	}, bucketedUserConfig.FeatureVariationMap)
-	variableType, value, featureId, variationId, err := generateBucketedVariableForUser("test", user, "num-var", clientCustomData)
-	require.Equal(t, VariableTypesNumber, variableType)
-	require.Equal(t, "614ef6aa473928459060721a", featureId)
-	require.Equal(t, "615357cf7e9ebdca58446ed0", variationId)
+	evaluation, err := generateBucketedVariableForUser("test", user, "num-var", clientCustomData)
+	require.Equal(t, VariableTypesNumber, evaluation.Type)
+	require.Equal(t, "614ef6aa473928459060721a", evaluation.FeatureId)
+	require.Equal(t, "615357cf7e9ebdca58446ed0", evaluation.VariationId)
 	require.NoError(t, err)
-	require.Equal(t, 610.61, value)
+	require.Equal(t, 610.61, evaluation.Value)
 
 	// Test user with matching private custom data and no global client custom data
 	userWithPrivateCustomData := api.User{
@@ -512,12 +512,12 @@ func TestClientData(t *testing.T) {
 		"614ef6aa473928459060721a": "615357cf7e9ebdca58446ed0",
 		"614ef6aa475928459060721a": "615382338424cb11646d7667",
 	}, bucketedUserConfig.FeatureVariationMap)
-	variableType, value, featureId, variationId, err = generateBucketedVariableForUser("test", userWithPrivateCustomData, "num-var", clientCustomData)
-	require.Equal(t, VariableTypesNumber, variableType)
-	require.Equal(t, "614ef6aa473928459060721a", featureId)
-	require.Equal(t, "615357cf7e9ebdca58446ed0", variationId)
+	evaluation, err = generateBucketedVariableForUser("test", userWithPrivateCustomData, "num-var", clientCustomData)
+	require.Equal(t, VariableTypesNumber, evaluation.Type)
+	require.Equal(t, "614ef6aa473928459060721a", evaluation.FeatureId)
+	require.Equal(t, "615357cf7e9ebdca58446ed0", evaluation.VariationId)
 	require.NoError(t, err)
-	require.Equal(t, 610.61, value)
+	require.Equal(t, 610.61, evaluation.Value)
 
 	// Test with a user that has custom data that doesn't match the feature
 	userWithWrongData := api.User{
@@ -573,13 +573,93 @@ func TestVariableForUser(t *testing.T) {
 	err := SetConfig(test_config, "test", "")
 	require.NoError(t, err)
 
-	variableType, value, featureId, variationId, err := generateBucketedVariableForUser("test", user, "json-var", nil)
+	evaluation, err := generateBucketedVariableForUser("test", user, "json-var", nil)
 	require.NoError(t, err)
-	require.Equal(t, VariableTypesJSON, variableType)
-	require.Equal(t, "614ef6aa473928459060721a", featureId)
-	require.Equal(t, "615357cf7e9ebdca58446ed0", variationId)
-	require.Equal(t, "{\"hello\":\"world\",\"num\":610,\"bool\":true}", value)
+	require.Equal(t, VariableTypesJSON, evaluation.Type)
+	require.Equal(t, "614ef6aa473928459060721a", evaluation.FeatureId)
+	require.Equal(t, "615357cf7e9ebdca58446ed0", evaluation.VariationId)
+	require.Equal(t, "{\"hello\":\"world\",\"num\":610,\"bool\":true}", evaluation.Value)
+	require.Equal(t, api.EvalReasonTargetingMatch, evaluation.Reason)
+}
+
+func TestEvaluateVariableForUser_Reasons(t *testing.T) {
+	err := SetConfig(test_config, "test", "")
+	require.NoError(t, err)
+	eventQueue, err := NewEventQueue("test", &api.EventQueueOptions{DisableAutomaticEventLogging: true, DisableCustomEventLogging: true}, &api.PlatformData{})
+	require.NoError(t, err)
+	defer eventQueue.Close()
+
+	qualifyingUser := api.User{
+		UserId: "CPopultest",
+		CustomData: map[string]interface{}{
+			"favouriteDrink": "coffee",
+			"favouriteFood":  "pizza",
+		},
+	}.GetPopulatedUser(&api.PlatformData{
+		PlatformVersion: "1.1.2",
+	})
+
+	evaluation, err := EvaluateVariableForUser("test", qualifyingUser, "json-var", VariableTypesJSON, eventQueue, nil)
+	require.NoError(t, err)
+	require.Equal(t, api.EvalReasonTargetingMatch, evaluation.Reason)
+	require.Equal(t, "614ef6aa473928459060721a", evaluation.FeatureId)
+	require.NotEmpty(t, evaluation.TargetId)
+	require.NotEmpty(t, evaluation.VariationKey)
+
+	evaluation, err = EvaluateVariableForUser("test", qualifyingUser, "json-var", VariableTypesString, eventQueue, nil)
+	require.ErrorIs(t, err, ErrInvalidVariableType)
+	require.Equal(t, api.EvalReasonTypeMismatch, evaluation.Reason)
+
+	evaluation, err = EvaluateVariableForUser("test", qualifyingUser, "no-such-variable", VariableTypesString, eventQueue, nil)
+	require.ErrorIs(t, err, ErrMissingVariable)
+	require.Equal(t, api.EvalReasonVariableNotFound, evaluation.Reason)
+
+	nonQualifyingUser := api.User{UserId: "hates-pizza"}.GetPopulatedUser(&api.PlatformData{PlatformVersion: "1.1.2"})
+	evaluation, err = EvaluateVariableForUser("test", nonQualifyingUser, "num-var", VariableTypesNumber, eventQueue, nil)
+	require.ErrorIs(t, err, ErrUserDoesNotQualifyForTargets)
+	require.Equal(t, api.EvalReasonNoTargetMatch, evaluation.Reason)
+	require.Equal(t, "614ef6aa473928459060721a", evaluation.FeatureId)
+	require.Nil(t, evaluation.Value)
+
+	evaluation, err = EvaluateVariableForUser("not-initialized", qualifyingUser, "num-var", VariableTypesNumber, eventQueue, nil)
+	require.ErrorIs(t, err, ErrConfigNotInitialized)
+	require.Equal(t, api.EvalReasonNotInitialized, evaluation.Reason)
+}
 
+func TestEvaluateVariablesForUser(t *testing.T) {
+	err := SetConfig(test_config, "test", "")
+	require.NoError(t, err)
+	eventQueue, err := NewEventQueue("test", &api.EventQueueOptions{DisableAutomaticEventLogging: true, DisableCustomEventLogging: true}, &api.PlatformData{})
+	require.NoError(t, err)
+	defer eventQueue.Close()
+
+	user := api.User{
+		UserId: "CPopultest",
+		CustomData: map[string]interface{}{
+			"favouriteDrink": "coffee",
+			"favouriteFood":  "pizza",
+		},
+	}.GetPopulatedUser(&api.PlatformData{
+		PlatformVersion: "1.1.2",
+	})
+
+	evaluations, err := EvaluateVariablesForUser("test", user, map[string]string{
+		"json-var":         VariableTypesJSON,
+		"num-var":          VariableTypesString,
+		"no-such-variable": VariableTypesBool,
+	}, eventQueue, nil)
+	require.NoError(t, err)
+	require.Len(t, evaluations, 3)
+
+	single, err := EvaluateVariableForUser("test", user, "json-var", VariableTypesJSON, eventQueue, nil)
+	require.NoError(t, err)
+	require.Equal(t, single, evaluations["json-var"])
+
+	require.Equal(t, api.EvalReasonTypeMismatch, evaluations["num-var"].Reason)
+	require.Equal(t, api.EvalReasonVariableNotFound, evaluations["no-such-variable"].Reason)
+
+	_, err = EvaluateVariablesForUser("not-initialized", user, map[string]string{"json-var": VariableTypesJSON}, eventQueue, nil)
+	require.ErrorIs(t, err, ErrConfigNotInitialized)
 }
 
 func TestGenerateBucketedConfig_MissingDistribution(t *testing.T) {
@@ -627,3 +707,135 @@ func TestGenerateBucketedConfig_MissingVariables(t *testing.T) {
 	_, err = GenerateBucketedConfig("broken_config", user, nil)
 	require.ErrorIs(t, err, ErrMissingVariable)
 }
+
+func TestBucketingValue(t *testing.T) {
+	user := api.User{
+		UserId:            "user",
+		Email:             "user@company.com",
+		CustomData:        map[string]interface{}{"company": "acme", "seats": 25.0, "internal": true, "tags": []interface{}{"a"}},
+		PrivateCustomData: map[string]interface{}{"company": "acme-private"},
+	}.GetPopulatedUser(&api.PlatformData{Platform: "Go"})
+	inputs := evaluationInputs{clientCustomData: map[string]interface{}{"region": "eu"}}
+
+	testCases := []struct {
+		targetKey string
+		engineKey string
+		key       string
+		value     string
+	}{
+		{"", "", "user_id", "user"},
+		{"user_id", "email", "user_id", "user"},
+		{"", "email", "email", "user@company.com"},
+		{"email", "company", "email", "user@company.com"},
+		{"platform", "", "platform", "Go"},
+		{"company", "", "company", "acme-private"},
+		{"seats", "", "seats", "25"},
+		{"internal", "", "internal", "true"},
+		{"region", "", "region", "eu"},
+		// Missing, empty and non-scalar values fall back to the user_id
+		{"tags", "", "user_id", "user"},
+		{"missing", "email", "user_id", "user"},
+		{"country", "", "user_id", "user"},
+	}
+	for _, tc := range testCases {
+		inputs.bucketingKey = tc.engineKey
+		key, value := inputs.bucketingValue(&user, &Target{BucketingKey: tc.targetKey})
+		require.Equal(t, tc.key, key, "target key %q, engine key %q", tc.targetKey, tc.engineKey)
+		require.Equal(t, tc.value, value, "target key %q, engine key %q", tc.targetKey, tc.engineKey)
+	}
+}
+
+func TestBucketing_BucketingKey(t *testing.T) {
+	config, err := newConfig(test_config, "")
+	require.NoError(t, err)
+	feature := config.GetFeatureForVariableId("61538237b0a70b58ae6af71f")
+	require.NotNil(t, feature)
+	for _, target := range feature.Configuration.Targets {
+		target.Rollout = &Rollout{Type: "gradual", StartPercentage: 0.5, StartDate: time.Now().Add(-time.Hour)}
+	}
+
+	company := func(userId string, company string) api.PopulatedUser {
+		return api.User{UserId: userId, Email: "test@email.com", CustomData: map[string]interface{}{"company": company}}.
+			GetPopulatedUser(&api.PlatformData{})
+	}
+	inputs := evaluationInputs{bucketingKey: "company"}
+
+	// Every user of a company gets the same rollout decision and variation
+	for _, companyName := range []string{"acme", "globex", "initech", "umbrella"} {
+		first, firstErr := doesUserQualifyForFeature(config, feature, company(uuid.New().String(), companyName), inputs)
+		for i := 0; i < 20; i++ {
+			th, err := doesUserQualifyForFeature(config, feature, company(uuid.New().String(), companyName), inputs)
+			require.Equal(t, firstErr, err)
+			require.Equal(t, "company", th.BucketingKey)
+			require.Equal(t, first.Hashes, th.Hashes)
+		}
+	}
+
+	// Users without the key are bucketed by user_id, like without a bucketing key
+	user := api.User{UserId: "no-company", Email: "test@email.com"}.GetPopulatedUser(&api.PlatformData{})
+	withKey, withKeyErr := doesUserQualifyForFeature(config, feature, user, inputs)
+	withoutKey, withoutKeyErr := doesUserQualifyForFeature(config, feature, user, evaluationInputs{})
+	require.Equal(t, withoutKeyErr, withKeyErr)
+	require.Equal(t, withoutKey.Hashes, withKey.Hashes)
+	require.Equal(t, "user_id", withKey.BucketingKey)
+
+	// A target's bucketing key takes precedence
+	for _, target := range feature.Configuration.Targets {
+		target.BucketingKey = "email"
+	}
+	th, _ := doesUserQualifyForFeature(config, feature, company("user", "acme"), inputs)
+	require.Equal(t, "email", th.BucketingKey)
+}
+
+func TestGenerateBucketedConfig_BucketingKey(t *testing.T) {
+	config, err := newConfig(test_config, "")
+	require.NoError(t, err)
+	user := api.User{UserId: "user", Email: "test@email.com", CustomData: map[string]interface{}{"company": "acme"}}.
+		GetPopulatedUser(&api.PlatformData{})
+
+	bucketedConfig, err := generateBucketedConfig(config, user, evaluationInputs{})
+	require.NoError(t, err)
+	require.NotEmpty(t, bucketedConfig.Features)
+	for _, feature := range bucketedConfig.Features {
+		require.Equal(t, "user_id", feature.BucketingKey)
+	}
+
+	bucketedConfig, err = generateBucketedConfig(config, user, evaluationInputs{bucketingKey: "company"})
+	require.NoError(t, err)
+	for _, feature := range bucketedConfig.Features {
+		require.Equal(t, "company", feature.BucketingKey)
+	}
+}
+
+func TestGetCurrentRolloutPercentage_LinearInterpolation(t *testing.T) {
+	start := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	rollout := Rollout{
+		Type:            "gradual",
+		StartPercentage: 0.2,
+		StartDate:       start,
+		Stages: []RolloutStage{
+			{Type: "linear", Date: start.Add(10 * time.Hour), Percentage: 0.6},
+			{Type: "discrete", Date: start.Add(20 * time.Hour), Percentage: 0.9},
+		},
+	}
+
+	testCases := []struct {
+		at         time.Duration
+		percentage float64
+	}{
+		{-time.Hour, 0},
+		{time.Millisecond, 0.2},
+		{5 * time.Hour, 0.4},
+		{7*time.Hour + 30*time.Minute, 0.5},
+		{10*time.Hour + time.Millisecond, 0.6},
+		{15 * time.Hour, 0.6},
+		{20*time.Hour + time.Millisecond, 0.9},
+	}
+	for _, tc := range testCases {
+		require.InDelta(t, tc.percentage, getCurrentRolloutPercentage(rollout, start.Add(tc.at)), 1e-6, tc.at)
+	}
+
+	// Rollouts are evaluated at the given time
+	require.False(t, doesUserPassRollout(rollout, 0.45, start.Add(5*time.Hour)))
+	require.True(t, doesUserPassRollout(rollout, 0.45, start.Add(7*time.Hour+30*time.Minute)))
+}
@@ -1,38 +1,21 @@
 package bucketing
 
-import (
-	"fmt"
-	"sync"
-)
-
-var internalConfigs = make(map[string]*configBody)
-var configMutex = &sync.RWMutex{}
-
 func getConfig(sdkKey string) (*configBody, error) {
-	configMutex.RLock()
-	defer configMutex.RUnlock()
-	if val, ok := internalConfigs[sdkKey]; ok && val != nil {
-		return val, nil
-	}
-	return nil, fmt.Errorf("config not initialized")
+	return engineForSDKKey(sdkKey).getConfig()
 }
 
+// SetConfig stores the config used by the package-level functions for sdkKey, merging its keys into eventQueue if given.
 func SetConfig(rawJSON []byte, sdkKey, etag string, eventQueue ...*EventQueue) error {
-	configMutex.Lock()
-	defer configMutex.Unlock()
-	config, err := newConfig(rawJSON, etag)
-	if err != nil {
-		return err
-	}
-	internalConfigs[sdkKey] = config
+	var queue *EventQueue
 	if len(eventQueue) > 0 {
-		eventQueue[0].MergeAggEventQueueKeys(config)
+		queue = eventQueue[0]
 	}
-	return nil
+	return engineForSDKKey(sdkKey).setConfig(rawJSON, etag, queue)
 }
 
 func clearConfigs() {
-	configMutex.Lock()
-	defer configMutex.Unlock()
-	internalConfigs = make(map[string]*configBody)
+	sdkKeyEngines.Range(func(_, engine any) bool {
+		engine.(*Engine).clearConfig()
+		return true
+	})
 }
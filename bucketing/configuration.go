@@ -9,5 +9,3 @@ type NativeBucketingConfiguration struct {
 	DisableAutomaticEventLogging bool          `json:"disableAutomaticEventLogging"`
 	DisableCustomEventLogging    bool          `json:"disableCustomEventLogging"`
 }
-
-var configuration = NativeBucketingConfiguration{}
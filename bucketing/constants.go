@@ -32,6 +32,9 @@ const (
 	SubTypePlatformVersion = "platformVersion"
 	SubTypeAppVersion      = "appVersion"
 	SubTypeDeviceModel     = "deviceModel"
+	SubTypeName            = "name"
+	SubTypeLanguage        = "language"
+	SubTypeAppBuild        = "appBuild"
 	SubTypeCustomData      = "customData"
 )
 
@@ -46,8 +49,18 @@ const (
 	ComparatorNotExist     = "!exist"
 	ComparatorContain      = "contain"
 	ComparatorNotContain   = "!contain"
+	ComparatorStartWith    = "startWith"
+	ComparatorNotStartWith = "!startWith"
+	ComparatorEndWith      = "endWith"
+	ComparatorNotEndWith   = "!endWith"
+	ComparatorRegex        = "regex"
+	ComparatorNotRegex     = "!regex"
 )
 
+// ComparatorIgnoreCaseSuffix makes a string comparator case-insensitive when appended to it, e.g. "endWithIgnoreCase".
+// It applies to =, !=, contain, startWith, endWith, regex and their negations.
+const ComparatorIgnoreCaseSuffix = "IgnoreCase"
+
 const (
 	DataKeyTypeString  = "String"
 	DataKeyTypeBoolean = "Boolean"
@@ -0,0 +1,217 @@
+package bucketing
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/BIwashi/go-server-sdk/v2/api"
+)
+
+// Engine evaluates users against a config. It owns its config, client custom data and event queue, so
+// engines do not share state even when they are created for the same SDK key.
+type Engine struct {
+	sdkKey string
+
+	// config is replaced as a whole and never modified once published, so readers need no lock
+	config atomic.Pointer[configBody]
+
+	// mutex guards the client custom data and the stores
+	mutex            sync.RWMutex
+	clientCustomData map[string]interface{}
+	optIns           api.OptInStore
+	userData         api.UserDataStore
+	bucketingKey     string
+	clock            api.Clock
+
+	eventQueue *EventQueue
+}
+
+// NewEngine creates an engine with its own event queue.
+func NewEngine(sdkKey string, options *api.EventQueueOptions, platformData *api.PlatformData) (*Engine, error) {
+	engine := &Engine{sdkKey: sdkKey}
+	eventQueue, err := newEventQueue(engine, options, platformData)
+	if err != nil {
+		return nil, err
+	}
+	engine.eventQueue = eventQueue
+	return engine, nil
+}
+
+// EventQueue returns the queue that the engine reports evaluations and user events to.
+func (e *Engine) EventQueue() *EventQueue {
+	return e.eventQueue
+}
+
+// SetConfig parses and stores the config used by later evaluations. Parsing happens before the config
+// is published, so evaluations running meanwhile keep using the previous config.
+func (e *Engine) SetConfig(rawJSON []byte, etag string) error {
+	return e.setConfig(rawJSON, etag, e.eventQueue)
+}
+
+func (e *Engine) setConfig(rawJSON []byte, etag string, eventQueue *EventQueue) error {
+	config, err := newConfig(rawJSON, etag)
+	if err != nil {
+		return err
+	}
+	e.config.Store(config)
+	if eventQueue != nil {
+		eventQueue.MergeAggEventQueueKeys(config)
+	}
+	return nil
+}
+
+func (e *Engine) getConfig() (*configBody, error) {
+	config := e.config.Load()
+	if config == nil {
+		return nil, ErrConfigNotInitialized
+	}
+	return config, nil
+}
+
+func (e *Engine) clearConfig() {
+	e.config.Store(nil)
+}
+
+// SetClientCustomData replaces the custom data used for segmentation. The map is copied, so the caller
+// may keep modifying it.
+func (e *Engine) SetClientCustomData(data map[string]interface{}) {
+	var customData map[string]interface{}
+	if data != nil {
+		customData = make(map[string]interface{}, len(data))
+		for key, value := range data {
+			customData[key] = value
+		}
+	}
+
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	e.clientCustomData = customData
+}
+
+// ClientCustomData returns the custom data used for segmentation. The returned map must not be modified.
+func (e *Engine) ClientCustomData() map[string]interface{} {
+	e.mutex.RLock()
+	defer e.mutex.RUnlock()
+	return e.clientCustomData
+}
+
+// SetOptInStore sets the store that opt-in filters read. Without a store, opt-in filters never pass.
+func (e *Engine) SetOptInStore(store api.OptInStore) {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	e.optIns = store
+}
+
+// SetBucketingKey sets the user field or custom data key that buckets users into the targets that don't name
+// their own bucketing key. An empty key buckets them by user_id.
+func (e *Engine) SetBucketingKey(key string) {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	e.bucketingKey = key
+}
+
+// SetClock sets the clock that rollouts are evaluated and events are timestamped with. A nil clock uses the
+// system time.
+func (e *Engine) SetClock(clock api.Clock) {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	e.clock = clock
+}
+
+// now returns the time of the engine's clock.
+func (e *Engine) now() time.Time {
+	return e.evaluationInputs().now()
+}
+
+// SetUserDataStore sets the store that keeps the custom data of users between evaluations of projects with
+// EdgeDB enabled. Without a store, users are evaluated with the custom data they are sent with only.
+func (e *Engine) SetUserDataStore(store api.UserDataStore) {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	e.userData = store
+}
+
+// FeatureIdForKey returns the id of the feature with the key in the current config.
+func (e *Engine) FeatureIdForKey(key string) (string, error) {
+	config, err := e.getConfig()
+	if err != nil {
+		return "", err
+	}
+	feature := config.GetFeatureForKey(key)
+	if feature == nil {
+		return "", fmt.Errorf("%w: %s", ErrMissingFeatureForKey, key)
+	}
+	return feature.Id, nil
+}
+
+func (e *Engine) evaluationInputs() evaluationInputs {
+	e.mutex.RLock()
+	defer e.mutex.RUnlock()
+	return evaluationInputs{
+		clientCustomData: e.clientCustomData,
+		optIns:           e.optIns,
+		userData:         e.userData,
+		bucketingKey:     e.bucketingKey,
+		clock:            e.clock,
+	}
+}
+
+// GenerateBucketedConfig buckets the user for every feature in the config.
+func (e *Engine) GenerateBucketedConfig(user api.PopulatedUser) (*api.BucketedUserConfig, error) {
+	config, err := e.getConfig()
+	if err != nil {
+		return nil, err
+	}
+	return generateBucketedConfig(config, user, e.evaluationInputs())
+}
+
+// EvaluateVariable evaluates the variable for the user and queues the matching aggregate evaluation event.
+// The Reason is always set, even when an error is returned.
+func (e *Engine) EvaluateVariable(user api.PopulatedUser, variableKey string, expectedVariableType string) (VariableEvaluation, error) {
+	config, _ := e.getConfig()
+	return evaluateVariable(config, user, variableKey, expectedVariableType, nil, e.eventQueue, e.evaluationInputs())
+}
+
+// EvaluateVariableWithCheck evaluates the variable like EvaluateVariable, and defaults it with a type mismatch when
+// check rejects its value. Only the event of the final result is queued.
+func (e *Engine) EvaluateVariableWithCheck(user api.PopulatedUser, variableKey string, expectedVariableType string, check ValueCheck) (VariableEvaluation, error) {
+	config, _ := e.getConfig()
+	return evaluateVariable(config, user, variableKey, expectedVariableType, check, e.eventQueue, e.evaluationInputs())
+}
+
+// EvaluateVariableWithoutEvents evaluates the variable like EvaluateVariable, without queuing an event.
+func (e *Engine) EvaluateVariableWithoutEvents(user api.PopulatedUser, variableKey string, expectedVariableType string) (VariableEvaluation, error) {
+	config, _ := e.getConfig()
+	return evaluateVariable(config, user, variableKey, expectedVariableType, nil, nil, e.evaluationInputs())
+}
+
+// EvaluateVariables evaluates several variables for the user against a single config, like EvaluateVariablesForUser.
+func (e *Engine) EvaluateVariables(user api.PopulatedUser, expectedVariableTypes map[string]string) (map[string]VariableEvaluation, error) {
+	config, err := e.getConfig()
+	if err != nil {
+		return nil, err
+	}
+	return evaluateVariables(config, user, expectedVariableTypes, e.eventQueue, e.evaluationInputs()), nil
+}
+
+// Close stops the event queue of the engine.
+func (e *Engine) Close() error {
+	if e.eventQueue == nil {
+		return nil
+	}
+	return e.eventQueue.Close()
+}
+
+// sdkKeyEngines holds the engine shared by the package-level functions for each SDK key. These engines
+// have no event queue of their own.
+var sdkKeyEngines sync.Map
+
+func engineForSDKKey(sdkKey string) *Engine {
+	if engine, ok := sdkKeyEngines.Load(sdkKey); ok {
+		return engine.(*Engine)
+	}
+	engine, _ := sdkKeyEngines.LoadOrStore(sdkKey, &Engine{sdkKey: sdkKey})
+	return engine.(*Engine)
+}
@@ -0,0 +1,350 @@
+package bucketing
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/BIwashi/go-server-sdk/v2/api"
+)
+
+// largeConfig returns the large config fixture shared with the client tests.
+func largeConfig(tb testing.TB) []byte {
+	configJSON, err := os.ReadFile(filepath.Join("..", "testdata", "fixture_large_config.json"))
+	require.NoError(tb, err)
+	return configJSON
+}
+
+// servedVariableKeys returns the keys of the variables set by a feature of the config.
+func servedVariableKeys(tb testing.TB, configJSON []byte) []string {
+	config, err := newConfig(configJSON, "")
+	require.NoError(tb, err)
+	var keys []string
+	for _, variable := range config.Variables {
+		if config.GetFeatureForVariableId(variable.Id) != nil {
+			keys = append(keys, variable.Key)
+		}
+	}
+	return keys
+}
+
+// raceEnabled is set by race_test.go when the race detector is on.
+var raceEnabled bool
+
+func newTestEngine(t *testing.T, sdkKey string) *Engine {
+	engine, err := NewEngine(sdkKey, &api.EventQueueOptions{DisableAutomaticEventLogging: true, DisableCustomEventLogging: true}, &api.PlatformData{})
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = engine.Close() })
+	return engine
+}
+
+func TestEngine_IsolatedState(t *testing.T) {
+	configured := newTestEngine(t, "engine-test")
+	unconfigured := newTestEngine(t, "engine-test")
+	require.NoError(t, configured.SetConfig(test_config, "etag"))
+
+	user := api.User{UserId: "CPopultest"}.GetPopulatedUser(&api.PlatformData{PlatformVersion: "1.1.2"})
+	customData := map[string]interface{}{"favouriteDrink": "coffee", "favouriteFood": "pizza"}
+	configured.SetClientCustomData(customData)
+	customData["favouriteFood"] = "salad"
+
+	evaluation, err := configured.EvaluateVariable(user, "json-var", VariableTypesJSON)
+	require.NoError(t, err)
+	require.Equal(t, api.EvalReasonTargetingMatch, evaluation.Reason)
+
+	evaluation, err = unconfigured.EvaluateVariable(user, "json-var", VariableTypesJSON)
+	require.ErrorIs(t, err, ErrConfigNotInitialized)
+	require.Equal(t, api.EvalReasonNotInitialized, evaluation.Reason)
+	require.Nil(t, unconfigured.ClientCustomData())
+
+	// The package-level functions use their own state for the same SDK key
+	_, err = getConfig("engine-test")
+	require.ErrorIs(t, err, ErrConfigNotInitialized)
+	require.Nil(t, GetClientCustomData("engine-test"))
+
+	bucketedConfig, err := configured.GenerateBucketedConfig(user)
+	require.NoError(t, err)
+	require.Contains(t, bucketedConfig.Variables, "json-var")
+
+	evaluations, err := configured.EvaluateVariables(user, map[string]string{"json-var": VariableTypesJSON})
+	require.NoError(t, err)
+	require.Equal(t, api.EvalReasonTargetingMatch, evaluations["json-var"].Reason)
+}
+
+func TestEngine_ConcurrentUpdates(t *testing.T) {
+	engine := newTestEngine(t, "engine-test-concurrent")
+	require.NoError(t, engine.SetConfig(test_config, ""))
+	user := api.User{UserId: "CPopultest"}.GetPopulatedUser(&api.PlatformData{PlatformVersion: "1.1.2"})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(3)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 50; j++ {
+				engine.SetClientCustomData(map[string]interface{}{"favouriteDrink": "coffee", "favouriteFood": "pizza"})
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 50; j++ {
+				require.NoError(t, engine.SetConfig(test_config, ""))
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 50; j++ {
+				_, _ = engine.EvaluateVariable(user, "json-var", VariableTypesJSON)
+				_, _ = engine.GenerateBucketedConfig(user)
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func TestEngine_LargeConfig(t *testing.T) {
+	engine := newTestEngine(t, "engine-test-large")
+	require.NoError(t, engine.SetConfig(largeConfig(t), ""))
+
+	evaluation, err := engine.EvaluateVariable(largeConfigUser, "v-key-80", VariableTypesBool)
+	require.NoError(t, err)
+	require.Equal(t, api.EvalReasonTargetingMatch, evaluation.Reason)
+	require.Equal(t, "189f9d536c3a4038bd4ba9f47b1f806c", evaluation.TargetId)
+}
+
+// largeConfigUser passes the custom data and audience match filters of the large config.
+var largeConfigUser = api.User{
+	UserId:     "alloc-user",
+	CustomData: map[string]interface{}{"data-key-6": "iYI6uwZed0ip", "data-key-1": "xA8fuyGtFteKax"},
+}.GetPopulatedUser(&api.PlatformData{})
+
+func TestEngine_EvaluateVariableDoesNotAllocate(t *testing.T) {
+	if raceEnabled {
+		t.Skip("allocations are not representative with the race detector")
+	}
+	engine := newTestEngine(t, "engine-test-allocs")
+	require.NoError(t, engine.SetConfig(largeConfig(t), ""))
+	engine.SetClientCustomData(map[string]interface{}{"plan": "enterprise"})
+
+	for _, variableKey := range []string{"v-key-80", "v-key-26", "v-key-66", "v-key-37", "no-such-variable"} {
+		allocs := testing.AllocsPerRun(100, func() {
+			_, _ = engine.EvaluateVariable(largeConfigUser, variableKey, "")
+		})
+		require.Zero(t, allocs, variableKey)
+	}
+
+	// Users without custom data evaluate every filter before the target for all users
+	otherUser := api.User{UserId: "alloc-user"}.GetPopulatedUser(&api.PlatformData{})
+	allocs := testing.AllocsPerRun(100, func() {
+		_, _ = engine.EvaluateVariableWithoutEvents(otherUser, "v-key-65", "")
+	})
+	require.Zero(t, allocs)
+}
+
+func benchmarkEngineEvaluateVariable(b *testing.B, updateConfig bool) {
+	engine, err := NewEngine("engine-benchmark", &api.EventQueueOptions{DisableAutomaticEventLogging: true, DisableCustomEventLogging: true}, &api.PlatformData{})
+	require.NoError(b, err)
+	defer engine.Close()
+	configJSON := largeConfig(b)
+	require.NoError(b, engine.SetConfig(configJSON, ""))
+
+	done := make(chan struct{})
+	var updates sync.WaitGroup
+	if updateConfig {
+		updates.Add(1)
+		go func() {
+			defer updates.Done()
+			for i := 0; ; i++ {
+				select {
+				case <-done:
+					return
+				default:
+				}
+				err := engine.SetConfig(configJSON, fmt.Sprintf("etag-%d", i))
+				if err != nil {
+					b.Error(err)
+					return
+				}
+			}
+		}()
+	}
+
+	variableKeys := servedVariableKeys(b, configJSON)
+	b.ReportAllocs()
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		i := 0
+		for pb.Next() {
+			_, _ = engine.EvaluateVariable(largeConfigUser, variableKeys[i%len(variableKeys)], "")
+			i++
+		}
+	})
+	b.StopTimer()
+	close(done)
+	updates.Wait()
+}
+
+func BenchmarkEngine_EvaluateVariable(b *testing.B) {
+	benchmarkEngineEvaluateVariable(b, false)
+}
+
+func BenchmarkEngine_EvaluateVariable_ConcurrentConfigUpdates(b *testing.B) {
+	benchmarkEngineEvaluateVariable(b, true)
+}
+
+func BenchmarkEngine_SetConfig(b *testing.B) {
+	engine := &Engine{sdkKey: "engine-benchmark"}
+	configJSON := largeConfig(b)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		require.NoError(b, engine.SetConfig(configJSON, ""))
+	}
+}
+
+// optInConfig serves "beta-var" to users opted in to the "beta" feature.
+const optInConfig = `{
+	"project": {"_id": "project", "key": "project", "a0_organization": "org", "settings": {"optIn": {"enabled": %t}}},
+	"environment": {"_id": "environment", "key": "development"},
+	"features": [{
+		"_id": "beta-feature", "key": "beta", "type": "release",
+		"variations": [{"_id": "beta-on", "key": "on", "name": "On", "variables": [{"_var": "beta-var-id", "value": true}]}],
+		"configuration": {"_id": "beta-configuration", "targets": [{
+			"_id": "beta-target",
+			"_audience": {"_id": "beta-audience", "filters": {"operator": "and", "filters": [{"type": "optIn"}]}},
+			"distribution": [{"_variation": "beta-on", "percentage": 1}]
+		}]}
+	}],
+	"variables": [{"_id": "beta-var-id", "key": "beta-var", "type": "Boolean"}]
+}`
+
+type testOptInStore map[string]bool
+
+func (s testOptInStore) IsOptedIn(userId string, featureId string) bool {
+	return s[userId+"/"+featureId]
+}
+
+func (s testOptInStore) SetOptIn(userId string, featureId string, optedIn bool) error {
+	s[userId+"/"+featureId] = optedIn
+	return nil
+}
+
+func TestEngine_OptInFilter(t *testing.T) {
+	engine := newTestEngine(t, "engine-test-opt-in")
+	require.NoError(t, engine.SetConfig([]byte(fmt.Sprintf(optInConfig, true)), ""))
+	optedIn := api.User{UserId: "opted-in"}.GetPopulatedUser(&api.PlatformData{})
+	other := api.User{UserId: "other"}.GetPopulatedUser(&api.PlatformData{})
+
+	// Without a store nobody is opted in
+	evaluation, err := engine.EvaluateVariable(optedIn, "beta-var", VariableTypesBool)
+	require.ErrorIs(t, err, ErrUserDoesNotQualifyForTargets)
+	require.Equal(t, api.EvalReasonNoTargetMatch, evaluation.Reason)
+
+	featureId, err := engine.FeatureIdForKey("beta")
+	require.NoError(t, err)
+	require.Equal(t, "beta-feature", featureId)
+	_, err = engine.FeatureIdForKey("alpha")
+	require.ErrorIs(t, err, ErrMissingFeatureForKey)
+
+	store := testOptInStore{}
+	require.NoError(t, store.SetOptIn("opted-in", featureId, true))
+	engine.SetOptInStore(store)
+	evaluation, err = engine.EvaluateVariable(optedIn, "beta-var", VariableTypesBool)
+	require.NoError(t, err)
+	require.Equal(t, true, evaluation.Value)
+	_, err = engine.EvaluateVariable(other, "beta-var", VariableTypesBool)
+	require.ErrorIs(t, err, ErrUserDoesNotQualifyForTargets)
+
+	bucketedConfig, err := engine.GenerateBucketedConfig(optedIn)
+	require.NoError(t, err)
+	require.Contains(t, bucketedConfig.Features, "beta")
+
+	// Opt-in filters never pass when opt-in is disabled in the project settings
+	require.NoError(t, engine.SetConfig([]byte(fmt.Sprintf(optInConfig, false)), ""))
+	_, err = engine.EvaluateVariable(optedIn, "beta-var", VariableTypesBool)
+	require.ErrorIs(t, err, ErrUserDoesNotQualifyForTargets)
+}
+
+// edgeDBConfig serves "pro-var" to users with the "pro" plan in their custom data.
+const edgeDBConfig = `{
+	"project": {"_id": "project", "key": "project", "a0_organization": "org", "settings": {"edgeDB": {"enabled": %t}}},
+	"environment": {"_id": "environment", "key": "development"},
+	"features": [{
+		"_id": "pro-feature", "key": "pro", "type": "release",
+		"variations": [{"_id": "pro-on", "key": "on", "name": "On", "variables": [{"_var": "pro-var-id", "value": true}]}],
+		"configuration": {"_id": "pro-configuration", "targets": [{
+			"_id": "pro-target",
+			"_audience": {"_id": "pro-audience", "filters": {"operator": "and", "filters": [{
+				"type": "user", "subType": "customData", "dataKey": "plan", "dataKeyType": "String",
+				"comparator": "=", "values": ["pro"]
+			}]}},
+			"distribution": [{"_variation": "pro-on", "percentage": 1}]
+		}]}
+	}],
+	"variables": [{"_id": "pro-var-id", "key": "pro-var", "type": "Boolean"}]
+}`
+
+type testUserDataStore map[string]map[string]interface{}
+
+func (s testUserDataStore) Get(userId string) (map[string]interface{}, error) {
+	return s[userId], nil
+}
+
+func (s testUserDataStore) Merge(userId string, customData map[string]interface{}) (map[string]interface{}, error) {
+	merged, _ := api.MergeCustomData(s[userId], customData)
+	s[userId] = merged
+	return merged, nil
+}
+
+func TestEngine_UserDataStore(t *testing.T) {
+	engine := newTestEngine(t, "engine-test-user-data")
+	require.NoError(t, engine.SetConfig([]byte(fmt.Sprintf(edgeDBConfig, true)), ""))
+	store := testUserDataStore{}
+	engine.SetUserDataStore(store)
+
+	withPlan := api.User{UserId: "user", CustomData: map[string]interface{}{"plan": "pro"}}.GetPopulatedUser(&api.PlatformData{})
+	withoutPlan := api.User{UserId: "user", CustomData: map[string]interface{}{"seats": 3.0}}.GetPopulatedUser(&api.PlatformData{})
+	evaluation, err := engine.EvaluateVariable(withPlan, "pro-var", VariableTypesBool)
+	require.NoError(t, err)
+	require.Equal(t, true, evaluation.Value)
+	require.Equal(t, map[string]interface{}{"plan": "pro"}, store["user"])
+
+	// The stored plan is merged into later evaluations, and the new data is stored with it
+	evaluation, err = engine.EvaluateVariable(withoutPlan, "pro-var", VariableTypesBool)
+	require.NoError(t, err)
+	require.Equal(t, true, evaluation.Value)
+	require.Equal(t, map[string]interface{}{"plan": "pro", "seats": 3.0}, store["user"])
+	require.Equal(t, map[string]interface{}{"seats": 3.0}, withoutPlan.CustomData)
+
+	bucketedConfig, err := engine.GenerateBucketedConfig(withoutPlan)
+	require.NoError(t, err)
+	require.Contains(t, bucketedConfig.Features, "pro")
+
+	// Only evaluations queuing events store the data of the user
+	visited := api.User{UserId: "user", CustomData: map[string]interface{}{"visited": true}}.GetPopulatedUser(&api.PlatformData{})
+	evaluation, err = engine.EvaluateVariableWithoutEvents(visited, "pro-var", VariableTypesBool)
+	require.NoError(t, err)
+	require.Equal(t, true, evaluation.Value)
+	explanation, err := engine.Explain(visited, "pro-var")
+	require.NoError(t, err)
+	require.Equal(t, true, explanation.Value)
+	_, err = engine.GenerateBucketedConfig(visited)
+	require.NoError(t, err)
+	require.Equal(t, map[string]interface{}{"plan": "pro", "seats": 3.0}, store["user"])
+
+	// Custom data sent with the user takes precedence over stored data
+	free := api.User{UserId: "user", CustomData: map[string]interface{}{"plan": "free"}}.GetPopulatedUser(&api.PlatformData{})
+	_, err = engine.EvaluateVariable(free, "pro-var", VariableTypesBool)
+	require.ErrorIs(t, err, ErrUserDoesNotQualifyForTargets)
+	require.Equal(t, "free", store["user"]["plan"])
+
+	// The store is not used when EdgeDB is disabled in the project settings
+	require.NoError(t, engine.SetConfig([]byte(fmt.Sprintf(edgeDBConfig, false)), ""))
+	store["other"] = map[string]interface{}{"plan": "pro"}
+	other := api.User{UserId: "other"}.GetPopulatedUser(&api.PlatformData{})
+	_, err = engine.EvaluateVariable(other, "pro-var", VariableTypesBool)
+	require.ErrorIs(t, err, ErrUserDoesNotQualifyForTargets)
+}
@@ -22,6 +22,7 @@ type aggEventData struct {
 	variableKey string
 	featureId   string
 	variationId string
+	decision    experimentDecision
 }
 
 type userEventData struct {
@@ -35,6 +36,43 @@ type VariableAggMap map[string]FeatureAggMap
 type AggregateEventQueue map[string]VariableAggMap
 type UserEventQueue map[string]api.UserEventsBatchRecord
 
+// aggDecisionKey identifies aggregate evaluations of features decided by an experiment layer or holdout. They are
+// counted apart from the other evaluations so that their events can report the decision.
+type aggDecisionKey struct {
+	eventType   string
+	variableKey string
+	featureId   string
+	variationId string
+	decision    experimentDecision
+}
+
+type aggDecisionQueue map[aggDecisionKey]int64
+
+func (q aggDecisionQueue) buildEvents(userId string) []api.Event {
+	events := make([]api.Event, 0, len(q))
+	for key, count := range q {
+		metaData := map[string]interface{}{"_feature": key.featureId}
+		if key.variationId != "" {
+			metaData["_variation"] = key.variationId
+		}
+		if key.decision.LayerId != "" {
+			metaData["_layer"] = key.decision.LayerId
+		}
+		if key.decision.HoldoutId != "" {
+			metaData["_holdout"] = key.decision.HoldoutId
+		}
+		events = append(events, api.Event{
+			Type_:       key.eventType,
+			Target:      key.variableKey,
+			Value:       float64(count),
+			UserId:      userId,
+			MetaData:    metaData,
+			FeatureVars: make(map[string]string),
+		})
+	}
+	return events
+}
+
 func (u *UserEventQueue) BuildBatchRecords() []api.UserEventsBatchRecord {
 	var records []api.UserEventsBatchRecord
 	for _, record := range *u {
@@ -104,12 +142,14 @@ func (agg *AggregateEventQueue) BuildBatchRecords(platformData *api.PlatformData
 
 type EventQueue struct {
 	sdkKey              string
+	engine              *Engine
 	options             *api.EventQueueOptions
 	aggEventQueueRaw    chan aggEventData
 	userEventQueueRaw   chan userEventData
 	userEventQueue      UserEventQueue
 	userEventQueueCount int
 	aggEventQueue       AggregateEventQueue
+	aggDecisionQueue    aggDecisionQueue
 	stateMutex          *sync.RWMutex
 	httpClient          *http.Client
 	pendingPayloads     map[string]api.FlushPayload
@@ -120,7 +160,14 @@ type EventQueue struct {
 	platformData        *api.PlatformData
 }
 
+// NewEventQueue creates a queue that buckets user events with the config and client custom data set by the
+// package-level SetConfig and SetClientCustomData for sdkKey.
 func NewEventQueue(sdkKey string, options *api.EventQueueOptions, platformData *api.PlatformData) (*EventQueue, error) {
+	return newEventQueue(engineForSDKKey(sdkKey), options, platformData)
+}
+
+func newEventQueue(engine *Engine, options *api.EventQueueOptions, platformData *api.PlatformData) (*EventQueue, error) {
+	sdkKey := engine.sdkKey
 	if sdkKey == "" {
 		return nil, fmt.Errorf("sdk key is required")
 	}
@@ -130,11 +177,13 @@ func NewEventQueue(sdkKey string, options *api.EventQueueOptions, platformData *
 
 	eq := &EventQueue{
 		sdkKey:            sdkKey,
+		engine:            engine,
 		options:           options,
 		aggEventQueueRaw:  make(chan aggEventData, options.MaxEventQueueSize),
 		userEventQueueRaw: make(chan userEventData, options.MaxEventQueueSize),
 		userEventQueue:    make(map[string]api.UserEventsBatchRecord),
 		aggEventQueue:     make(AggregateEventQueue),
+		aggDecisionQueue:  make(aggDecisionQueue),
 		stateMutex:        &sync.RWMutex{},
 		httpClient: &http.Client{
 			// Set an explicit timeout so that we don't wait forever on a request
@@ -189,21 +238,25 @@ func (eq *EventQueue) QueueAggregateEvent(config api.BucketedUserConfig, event a
 }
 
 func (eq *EventQueue) queueAggregateEventInternal(variableKey, featureId, variationId, eventType string) error {
-	if eq.options != nil && eq.options.IsEventLoggingDisabled(eventType) {
+	return eq.queueAggEventData(aggEventData{
+		eventType:   eventType,
+		variableKey: variableKey,
+		featureId:   featureId,
+		variationId: variationId,
+	})
+}
+
+func (eq *EventQueue) queueAggEventData(event aggEventData) error {
+	if eq.options != nil && eq.options.IsEventLoggingDisabled(event.eventType) {
 		return nil
 	}
 
-	if variableKey == "" {
+	if event.variableKey == "" {
 		return fmt.Errorf("A variable key is required for aggregate events")
 	}
 
 	select {
-	case eq.aggEventQueueRaw <- aggEventData{
-		eventType:   eventType,
-		variableKey: variableKey,
-		featureId:   featureId,
-		variationId: variationId,
-	}:
+	case eq.aggEventQueueRaw <- event:
 	default:
 		eq.eventsDropped.Add(1)
 		return ErrQueueFull
@@ -213,6 +266,9 @@ func (eq *EventQueue) queueAggregateEventInternal(variableKey, featureId, variat
 }
 
 func (eq *EventQueue) QueueEvent(user api.User, event api.Event) error {
+	if event.ClientDate.IsZero() {
+		event.ClientDate = eq.engine.now()
+	}
 
 	select {
 	case eq.userEventQueueRaw <- userEventData{
@@ -242,15 +298,44 @@ func (eq *EventQueue) QueueVariableEvaluatedEvent(variableKey, featureId, variat
 	return eq.queueAggregateEventInternal(variableKey, featureId, variationId, eventType)
 }
 
+// queueVariableDecisionEvent queues the aggregate evaluation event of a variable whose feature was decided by an
+// experiment layer or holdout. Its event reports the feature and the decision even when the variable was defaulted.
+func (eq *EventQueue) queueVariableDecisionEvent(variableKey, featureId, variationId string, decision experimentDecision, variableDefaulted bool) error {
+	if eq.options.DisableAutomaticEventLogging {
+		return nil
+	}
+
+	eventType := api.EventType_AggVariableEvaluated
+	if variableDefaulted {
+		eventType = api.EventType_AggVariableDefaulted
+	}
+	return eq.queueAggEventData(aggEventData{
+		eventType:   eventType,
+		variableKey: variableKey,
+		featureId:   featureId,
+		variationId: variationId,
+		decision:    decision,
+	})
+}
+
 func (eq *EventQueue) FlushEventQueue() (map[string]api.FlushPayload, error) {
 	eq.stateMutex.Lock()
 	defer eq.stateMutex.Unlock()
 
 	var records []api.UserEventsBatchRecord
 
-	records = append(records, eq.aggEventQueue.BuildBatchRecords(eq.platformData))
+	aggRecord := eq.aggEventQueue.BuildBatchRecords(eq.platformData)
+	aggRecord.Events = append(aggRecord.Events, eq.aggDecisionQueue.buildEvents(aggRecord.User.UserId)...)
+	// Aggregate events are timestamped when they are flushed
+	now := eq.engine.now()
+	aggRecord.User.CreatedDate = now
+	for i := range aggRecord.Events {
+		aggRecord.Events[i].ClientDate = now
+	}
+	records = append(records, aggRecord)
 	records = append(records, eq.userEventQueue.BuildBatchRecords()...)
 	eq.aggEventQueue = make(AggregateEventQueue)
+	eq.aggDecisionQueue = make(aggDecisionQueue)
 	eq.userEventQueue = make(UserEventQueue)
 	eq.userEventQueueCount = 0
 
@@ -400,11 +485,15 @@ func (eq *EventQueue) processUserEvent(event userEventData) (err error) {
 	defer eq.stateMutex.Unlock()
 
 	// TODO: provide platform data
-	popU := event.user.GetPopulatedUser(eq.platformData)
-	ccd := GetClientCustomData(eq.sdkKey)
-	popU.MergeClientCustomData(ccd)
+	inputs := eq.engine.evaluationInputs()
+	popU := event.user.GetPopulatedUserWithTime(eq.platformData, inputs.now())
+	popU.MergeClientCustomData(inputs.clientCustomData)
 
-	bucketedConfig, err := GenerateBucketedConfig(eq.sdkKey, popU, ccd)
+	config, err := eq.engine.getConfig()
+	if err != nil {
+		return err
+	}
+	bucketedConfig, err := generateBucketedConfig(config, popU, inputs)
 	if err != nil {
 		return err
 	}
@@ -447,6 +536,17 @@ func (eq *EventQueue) processAggregateEvent(event aggEventData) (err error) {
 
 	eq.stateMutex.Lock()
 	defer eq.stateMutex.Unlock()
+	if event.decision != (experimentDecision{}) {
+		eq.aggDecisionQueue[aggDecisionKey{
+			eventType:   event.eventType,
+			variableKey: event.variableKey,
+			featureId:   event.featureId,
+			variationId: event.variationId,
+			decision:    event.decision,
+		}]++
+		return nil
+	}
+
 	eType := event.eventType
 	eTarget := event.variableKey
 
@@ -212,3 +212,37 @@ func TestEventQueue_QueueAndFlush(t *testing.T) {
 	require.Equal(t, 0, len(eq.userEventQueue))
 	require.Equal(t, 2, len(eq.pendingPayloads))
 }
+
+func TestEventQueue_ClockTimestamps(t *testing.T) {
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	engine, err := NewEngine("engine-test-event-clock", &api.EventQueueOptions{FlushEventsInterval: time.Hour}, (&api.PlatformData{}).Default())
+	require.NoError(t, err)
+	defer engine.Close()
+	engine.SetClock(fixedClock(now))
+	require.NoError(t, engine.SetConfig(test_config, ""))
+	eq := engine.EventQueue()
+
+	clientDate := now.Add(-time.Hour)
+	require.NoError(t, eq.QueueEvent(api.User{UserId: "testing"}, api.Event{Type_: "custom"}))
+	require.NoError(t, eq.QueueEvent(api.User{UserId: "testing"}, api.Event{Type_: "custom", ClientDate: clientDate}))
+	require.NoError(t, eq.processAggregateEvent(aggEventData{
+		eventType:   api.EventType_AggVariableEvaluated,
+		variableKey: "somevariablekey",
+		featureId:   "feature",
+		variationId: "variation",
+	}))
+	require.Eventually(t, func() bool { return eq.UserQueueLength() == 2 }, 10*time.Second, time.Millisecond)
+
+	payloads, err := eq.FlushEventQueue()
+	require.NoError(t, err)
+	var clientDates []time.Time
+	for _, payload := range payloads {
+		for _, record := range payload.Records {
+			require.Equal(t, now, record.User.CreatedDate)
+			for _, event := range record.Events {
+				clientDates = append(clientDates, event.ClientDate)
+			}
+		}
+	}
+	require.ElementsMatch(t, []time.Time{now, clientDate, now}, clientDates)
+}
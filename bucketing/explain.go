@@ -0,0 +1,222 @@
+package bucketing
+
+import (
+	"fmt"
+
+	"github.com/BIwashi/go-server-sdk/v2/api"
+)
+
+// Explain evaluates the variable for the user like EvaluateVariable, without queuing events, and traces every
+// target of the feature owning the variable, the result of each of their filters, and how the user was bucketed.
+// Evaluation errors are reported in the explanation; an error is only returned when no config is set.
+func (e *Engine) Explain(user api.PopulatedUser, variableKey string) (*api.Explanation, error) {
+	config, err := e.getConfig()
+	if err != nil {
+		return nil, err
+	}
+	inputs := e.evaluationInputs()
+	user = inputs.withStoredUserData(config, user, false)
+
+	evaluation, err := bucketVariableInConfig(config, user, variableKey, inputs, nil)
+	evaluation, err = completeVariableEvaluation(evaluation, err, variableKey, "", nil, nil)
+	explanation := &api.Explanation{
+		VariableKey: variableKey,
+		Targets:     []api.TargetTrace{},
+		Value:       evaluation.Value,
+		Eval:        evaluation.EvalDetails(),
+	}
+	if err != nil {
+		explanation.Error = err.Error()
+	}
+
+	variable := config.GetVariableForKey(variableKey)
+	if variable == nil {
+		return explanation, nil
+	}
+	feature := config.GetFeatureForVariableId(variable.Id)
+	if feature == nil {
+		return explanation, nil
+	}
+	explanation.FeatureId = feature.Id
+	explanation.FeatureKey = feature.Key
+
+	ctx := acquireFilterContext(&user, inputs.clientCustomData)
+	defer releaseFilterContext(ctx)
+	ctx.optIns = inputs.optIns
+	ctx.featureId = feature.Id
+	tracer := filterTracer{compiler: config.newFilterCompiler(), ctx: ctx, visiting: make(map[string]bool)}
+
+	selected := false
+	for _, target := range feature.Configuration.Targets {
+		trace := api.TargetTrace{TargetId: target.Id, Passed: config.targetFilter(target)(ctx)}
+		if target.Audience != nil {
+			trace.Audience = tracer.operator(target.Audience.Filters)
+		}
+		if trace.Passed && !selected {
+			selected = true
+			trace.Selected = true
+			explainBucketing(config, feature, target, &user, inputs, explanation, &trace)
+		}
+		explanation.Targets = append(explanation.Targets, trace)
+	}
+	return explanation, nil
+}
+
+// explainBucketing traces the rollout and distribution of the target the user was segmented into.
+func explainBucketing(config *configBody, feature *ConfigFeature, target *Target, user *api.PopulatedUser, inputs evaluationInputs, explanation *api.Explanation, trace *api.TargetTrace) {
+	explanation.BucketingKey, explanation.BucketingValue = inputs.bucketingValue(user, target)
+	decision, err := config.decideExperiment(feature, user, inputs)
+	if err != nil || decision.HoldoutId != "" {
+		// The layer or holdout decided the variation, as reported by the evaluation details
+		return
+	}
+
+	hashes := generateBoundedHashes(explanation.BucketingValue, target.Id)
+	if target.Rollout != nil {
+		percentage := getCurrentRolloutPercentage(*target.Rollout, inputs.now())
+		trace.Rollout = &api.RolloutTrace{
+			Type:        target.Rollout.Type,
+			Percentage:  percentage,
+			RolloutHash: hashes.RolloutHash,
+			Passed:      percentage != 0 && hashes.RolloutHash <= percentage,
+		}
+		if !trace.Rollout.Passed {
+			return
+		}
+	}
+
+	distribution := &api.DistributionTrace{
+		BucketingHash: hashes.BucketingHash,
+		Distribution:  make([]api.DistributionTraceVariation, len(target.Distribution)),
+	}
+	for i, d := range target.Distribution {
+		distribution.Distribution[i] = api.DistributionTraceVariation{VariationId: d.Variation, Percentage: d.Percentage}
+	}
+	if variationId, err := target.DecideTargetVariation(hashes.BucketingHash); err == nil {
+		distribution.VariationId = variationId
+		for _, variation := range feature.Variations {
+			if variation.Id == variationId {
+				distribution.VariationKey = variation.Key
+			}
+		}
+	}
+	trace.Distribution = distribution
+}
+
+// filterTracer traces filter trees for one user. Each filter is evaluated with its compiled predicate, so the
+// trace agrees with the evaluation.
+type filterTracer struct {
+	compiler *filterCompiler
+	ctx      *filterContext
+	// visiting holds the audiences being traced, so audiences matching each other are traced once
+	visiting map[string]bool
+}
+
+func (t filterTracer) operator(operator *AudienceOperator) *api.FilterTrace {
+	if operator == nil {
+		return &api.FilterTrace{}
+	}
+	trace := &api.FilterTrace{Operator: operator.Operator, Filters: make([]*api.FilterTrace, len(operator.Filters))}
+	passed, failed := 0, 0
+	for i, filter := range operator.Filters {
+		trace.Filters[i] = t.filter(filter)
+		if trace.Filters[i].Passed {
+			passed++
+		} else {
+			failed++
+		}
+	}
+	switch operator.Operator {
+	case OperatorAnd:
+		trace.Passed = len(operator.Filters) > 0 && failed == 0
+	case OperatorOr:
+		trace.Passed = passed > 0
+	}
+	return trace
+}
+
+func (t filterTracer) filter(filter FilterOrOperator) *api.FilterTrace {
+	switch filter := filter.(type) {
+	case nil:
+		return &api.FilterTrace{Type: "unknown"}
+	case *AudienceOperator:
+		return t.operator(filter)
+	case AudienceOperator:
+		return t.operator(&filter)
+	case *UserFilter:
+		return &api.FilterTrace{
+			Type:       TypeUser,
+			SubType:    filter.SubType,
+			Comparator: filter.GetComparator(),
+			Values:     filter.Values,
+			UserValue:  userFilterValue(&t.ctx.user, filter.SubType),
+			Passed:     t.compiler.compileFilter(filter)(t.ctx),
+		}
+	case *CustomDataFilter:
+		return &api.FilterTrace{
+			Type:       TypeUser,
+			SubType:    SubTypeCustomData,
+			Comparator: filter.GetComparator(),
+			DataKey:    filter.DataKey,
+			Values:     filter.Values,
+			UserValue:  t.ctx.customDataValue(filter.DataKey),
+			Passed:     t.compiler.compileFilter(filter)(t.ctx),
+		}
+	case *AudienceMatchFilter:
+		return t.audienceMatch(filter)
+	}
+
+	trace := &api.FilterTrace{Passed: t.compiler.compileFilter(filter)(t.ctx)}
+	switch filter.(type) {
+	case AllFilter, *AllFilter, PassFilter, *PassFilter:
+		trace.Type = TypeAll
+	case OptInFilter, *OptInFilter:
+		trace.Type = TypeOptIn
+	default:
+		trace.Type = fmt.Sprintf("%T", filter)
+	}
+	return trace
+}
+
+// audienceMatch traces the audiences of the filter, deciding like compileAudienceMatchFilter.
+func (t filterTracer) audienceMatch(filter *AudienceMatchFilter) *api.FilterTrace {
+	comparator := filter.GetComparator()
+	trace := &api.FilterTrace{Type: TypeAudienceMatch, Comparator: comparator}
+	decided := false
+	for _, id := range filter.Audiences {
+		trace.Values = append(trace.Values, id)
+		var audienceTrace *api.FilterTrace
+		audience, ok := t.compiler.audiences[id]
+		if ok && !t.visiting[id] {
+			t.visiting[id] = true
+			audienceTrace = t.operator(audience.Filters)
+			delete(t.visiting, id)
+		} else {
+			audienceTrace = &api.FilterTrace{}
+		}
+		audienceTrace.AudienceId = id
+		trace.Filters = append(trace.Filters, audienceTrace)
+
+		if decided {
+			continue
+		}
+		if !ok {
+			decided = true
+		} else if audienceTrace.Passed {
+			trace.Passed = comparator == ComparatorEqual
+			decided = true
+		}
+	}
+	if !decided {
+		trace.Passed = comparator == ComparatorNotEqual
+	}
+	return trace
+}
+
+// userFilterValue returns the value of the user that filters of the subtype compare.
+func userFilterValue(user *api.PopulatedUser, subType string) string {
+	if subType == SubTypeUserID {
+		return user.UserId
+	}
+	return userBucketingValue(user, subType, nil)
+}
@@ -0,0 +1,146 @@
+package bucketing
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/BIwashi/go-server-sdk/v2/api"
+)
+
+// explainConfig serves "explain-var" to VIP users and to users of the "beta" audience on the pro plan, who are
+// rolled out to and split between two variations.
+const explainConfig = `{
+	"project": {"_id": "project", "key": "project", "a0_organization": "org"},
+	"environment": {"_id": "environment", "key": "development"},
+	"audiences": {
+		"beta": {"filters": {"operator": "or", "filters": [
+			{"type": "user", "subType": "country", "comparator": "=", "values": ["CA"]}
+		]}}
+	},
+	"features": [{
+		"_id": "explain-feature", "key": "explain", "type": "release",
+		"variations": [
+			{"_id": "explain-a", "key": "a", "name": "A", "variables": [{"_var": "explain-var-id", "value": "a"}]},
+			{"_id": "explain-b", "key": "b", "name": "B", "variables": [{"_var": "explain-var-id", "value": "b"}]}
+		],
+		"configuration": {"_id": "explain-configuration", "targets": [
+			{
+				"_id": "vip-target",
+				"_audience": {"_id": "vip-audience", "filters": {"operator": "and", "filters": [
+					{"type": "user", "subType": "email", "comparator": "=", "values": ["vip@example.com"]}
+				]}},
+				"distribution": [{"_variation": "explain-a", "percentage": 1}]
+			},
+			{
+				"_id": "beta-target",
+				"_audience": {"_id": "beta-target-audience", "filters": {"operator": "and", "filters": [
+					{"type": "audienceMatch", "comparator": "=", "_audiences": ["beta"]},
+					{"operator": "or", "filters": [
+						{"type": "user", "subType": "customData", "dataKey": "plan", "dataKeyType": "String", "comparator": "=", "values": ["pro"]},
+						{"type": "user", "subType": "platform", "comparator": "=", "values": ["unknown"]}
+					]}
+				]}},
+				"rollout": {"type": "schedule", "startDate": "2020-01-01T00:00:00Z"},
+				"distribution": [{"_variation": "explain-a", "percentage": 0.5}, {"_variation": "explain-b", "percentage": 0.5}]
+			},
+			{
+				"_id": "all-target",
+				"_audience": {"_id": "all-audience", "filters": {"operator": "and", "filters": [{"type": "all"}]}},
+				"distribution": [{"_variation": "explain-a", "percentage": 1}]
+			}
+		]}
+	}],
+	"variables": [{"_id": "explain-var-id", "key": "explain-var", "type": "String"}]
+}`
+
+func TestEngine_Explain(t *testing.T) {
+	engine := newTestEngine(t, "engine-test-explain")
+	_, err := engine.Explain(api.User{UserId: "user"}.GetPopulatedUser(&api.PlatformData{}), "explain-var")
+	require.ErrorIs(t, err, ErrConfigNotInitialized)
+	require.NoError(t, engine.SetConfig([]byte(explainConfig), ""))
+
+	user := api.User{
+		UserId:     "user",
+		Email:      "someone@example.com",
+		Country:    "CA",
+		CustomData: map[string]interface{}{"plan": "pro"},
+	}.GetPopulatedUser(&api.PlatformData{})
+	explanation, err := engine.Explain(user, "explain-var")
+	require.NoError(t, err)
+	evaluation, err := engine.EvaluateVariableWithoutEvents(user, "explain-var", VariableTypesString)
+	require.NoError(t, err)
+	require.Equal(t, evaluation.Value, explanation.Value)
+	require.Equal(t, evaluation.EvalDetails(), explanation.Eval)
+	require.Equal(t, "explain-feature", explanation.FeatureId)
+	require.Equal(t, "explain", explanation.FeatureKey)
+	require.Equal(t, "user_id", explanation.BucketingKey)
+	require.Equal(t, "user", explanation.BucketingValue)
+	require.Len(t, explanation.Targets, 3)
+
+	vip := explanation.Targets[0]
+	require.Equal(t, "vip-target", vip.TargetId)
+	require.False(t, vip.Passed)
+	require.False(t, vip.Selected)
+	require.Equal(t, []interface{}{"vip@example.com"}, vip.Audience.Filters[0].Values)
+	require.Equal(t, "someone@example.com", vip.Audience.Filters[0].UserValue)
+
+	beta := explanation.Targets[1]
+	require.True(t, beta.Passed)
+	require.True(t, beta.Selected)
+	require.True(t, beta.Audience.Passed)
+	audienceMatch := beta.Audience.Filters[0]
+	require.Equal(t, TypeAudienceMatch, audienceMatch.Type)
+	require.True(t, audienceMatch.Passed)
+	require.Equal(t, "beta", audienceMatch.Filters[0].AudienceId)
+	require.Equal(t, "CA", audienceMatch.Filters[0].Filters[0].UserValue)
+	nested := beta.Audience.Filters[1]
+	require.Equal(t, OperatorOr, nested.Operator)
+	require.True(t, nested.Passed)
+	require.Equal(t, "plan", nested.Filters[0].DataKey)
+	require.Equal(t, "pro", nested.Filters[0].UserValue)
+	require.True(t, nested.Filters[0].Passed)
+	require.False(t, nested.Filters[1].Passed)
+
+	hashes := generateBoundedHashes("user", "beta-target")
+	require.Equal(t, &api.RolloutTrace{Type: "schedule", Percentage: 1, RolloutHash: hashes.RolloutHash, Passed: true}, beta.Rollout)
+	require.Equal(t, hashes.BucketingHash, beta.Distribution.BucketingHash)
+	require.Len(t, beta.Distribution.Distribution, 2)
+	require.Equal(t, evaluation.VariationId, beta.Distribution.VariationId)
+	require.Equal(t, evaluation.VariationKey, beta.Distribution.VariationKey)
+
+	// Later targets are traced without bucketing the user
+	all := explanation.Targets[2]
+	require.True(t, all.Passed)
+	require.False(t, all.Selected)
+	require.Nil(t, all.Distribution)
+
+	rendered, err := json.Marshal(explanation)
+	require.NoError(t, err)
+	var decoded api.Explanation
+	require.NoError(t, json.Unmarshal(rendered, &decoded))
+	require.Equal(t, "beta-target", decoded.Targets[1].TargetId)
+	require.Contains(t, string(rendered), `"_audience":"beta"`)
+}
+
+func TestEngine_Explain_Defaulted(t *testing.T) {
+	engine := newTestEngine(t, "engine-test-explain-defaulted")
+	require.NoError(t, engine.SetConfig([]byte(explainConfig), ""))
+
+	explanation, err := engine.Explain(api.User{UserId: "user"}.GetPopulatedUser(&api.PlatformData{}), "missing-var")
+	require.NoError(t, err)
+	require.Equal(t, api.EvalReasonVariableNotFound, explanation.Eval.Reason)
+	require.NotEmpty(t, explanation.Error)
+	require.Empty(t, explanation.Targets)
+
+	// Audience matches of audiences missing from the config fail
+	require.NoError(t, engine.SetConfig([]byte(strings.Replace(explainConfig, `"beta": {`, `"other": {`, 1)), ""))
+	user := api.User{UserId: "user", Country: "CA", CustomData: map[string]interface{}{"plan": "pro"}}.GetPopulatedUser(&api.PlatformData{})
+	explanation, err = engine.Explain(user, "explain-var")
+	require.NoError(t, err)
+	require.False(t, explanation.Targets[1].Passed)
+	require.False(t, explanation.Targets[1].Audience.Filters[0].Passed)
+	require.True(t, explanation.Targets[2].Selected)
+}
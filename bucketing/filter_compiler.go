@@ -0,0 +1,710 @@
+package bucketing
+
+import (
+	"math"
+	"net/netip"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/BIwashi/go-server-sdk/v2/api"
+)
+
+// filterPredicate is a filter tree compiled ahead of evaluation, so evaluating it neither walks the parsed
+// filters nor allocates.
+type filterPredicate func(ctx *filterContext) bool
+
+func passFilter(*filterContext) bool { return true }
+
+func failFilter(*filterContext) bool { return false }
+
+// filterContext is the view of the user shared by every filter evaluated for one user.
+// Contexts are pooled, as passing them to compiled filters moves them to the heap.
+type filterContext struct {
+	user             api.PopulatedUser
+	clientCustomData map[string]interface{}
+	// featureId is the feature being segmented, and optIns the features users opted in to
+	featureId string
+	optIns    api.OptInStore
+	// audiences are looked up by audience match filters evaluated on their own, through Evaluate
+	audiences map[string]NoIdAudience
+}
+
+var filterContextPool = sync.Pool{New: func() any { return new(filterContext) }}
+
+func acquireFilterContext(user *api.PopulatedUser, clientCustomData map[string]interface{}) *filterContext {
+	ctx := filterContextPool.Get().(*filterContext)
+	ctx.user = *user
+	ctx.clientCustomData = clientCustomData
+	return ctx
+}
+
+func releaseFilterContext(ctx *filterContext) {
+	*ctx = filterContext{}
+	filterContextPool.Put(ctx)
+}
+
+func evaluateFilter(predicate filterPredicate, audiences map[string]NoIdAudience, user api.PopulatedUser, clientCustomData map[string]interface{}) bool {
+	ctx := acquireFilterContext(&user, clientCustomData)
+	ctx.audiences = audiences
+	defer releaseFilterContext(ctx)
+	return predicate(ctx)
+}
+
+// customDataValue returns the value of a custom data key, looking in the private custom data, the custom data,
+// then the client custom data. This matches merging them with CombinedCustomData, without building a new map.
+func (ctx *filterContext) customDataValue(key string) interface{} {
+	if value, ok := ctx.user.PrivateCustomData[key]; ok {
+		return value
+	}
+	if value, ok := ctx.user.CustomData[key]; ok {
+		return value
+	}
+	return ctx.clientCustomData[key]
+}
+
+// filterCompiler compiles filter trees against the audiences of a config. Audience matches look up the
+// compiled audience when they are evaluated, so audiences can reference each other in any order.
+type filterCompiler struct {
+	audiences map[string]NoIdAudience
+	compiled  map[string]filterPredicate
+	// optInEnabled is the opt-in setting of the project, without which opt-in filters never pass
+	optInEnabled bool
+	// standalone compilers reuse the predicates cached by Initialize, and look up matched audiences in the context
+	standalone bool
+}
+
+// standaloneCompiler compiles the predicates run by the Evaluate methods of filters and operators.
+var standaloneCompiler = &filterCompiler{standalone: true}
+
+func newFilterCompiler(audiences map[string]NoIdAudience) *filterCompiler {
+	return &filterCompiler{audiences: audiences, compiled: make(map[string]filterPredicate, len(audiences))}
+}
+
+// compileAudiences compiles every audience up front. Afterwards evaluations only read the compiled audiences,
+// so the compiled filters are safe for concurrent use.
+func (c *filterCompiler) compileAudiences() {
+	for id := range c.audiences {
+		c.audience(id)
+	}
+}
+
+// audience returns the compiled filters of an audience, or nil if there is no such audience.
+func (c *filterCompiler) audience(id string) filterPredicate {
+	if predicate, ok := c.compiled[id]; ok {
+		return predicate
+	}
+	audience, ok := c.audiences[id]
+	if !ok {
+		return nil
+	}
+	predicate := c.compileOperator(audience.Filters)
+	c.compiled[id] = predicate
+	return predicate
+}
+
+func (c *filterCompiler) compileTarget(target *Target) filterPredicate {
+	if target.Audience == nil {
+		return failFilter
+	}
+	return c.compileOperator(target.Audience.Filters)
+}
+
+func (c *filterCompiler) compileOperator(operator *AudienceOperator) filterPredicate {
+	if operator == nil || len(operator.Filters) == 0 {
+		return failFilter
+	}
+	filters := make([]filterPredicate, len(operator.Filters))
+	for i, filter := range operator.Filters {
+		filters[i] = c.compileFilter(filter)
+	}
+
+	switch operator.Operator {
+	case OperatorOr:
+		if len(filters) == 1 {
+			return filters[0]
+		}
+		return func(ctx *filterContext) bool {
+			for _, filter := range filters {
+				if filter(ctx) {
+					return true
+				}
+			}
+			return false
+		}
+	case OperatorAnd:
+		if len(filters) == 1 {
+			return filters[0]
+		}
+		return func(ctx *filterContext) bool {
+			for _, filter := range filters {
+				if !filter(ctx) {
+					return false
+				}
+			}
+			return true
+		}
+	}
+	return failFilter
+}
+
+func (c *filterCompiler) compileFilter(filter FilterOrOperator) filterPredicate {
+	switch filter := filter.(type) {
+	case nil:
+		// Filters of a type this SDK does not know are left empty when parsing
+		return failFilter
+	case *AudienceOperator:
+		if c.standalone && filter.predicate != nil {
+			return filter.predicate
+		}
+		return c.compileOperator(filter)
+	case AudienceOperator:
+		if c.standalone && filter.predicate != nil {
+			return filter.predicate
+		}
+		return c.compileOperator(&filter)
+	case AllFilter, *AllFilter, PassFilter, *PassFilter:
+		return passFilter
+	case OptInFilter, *OptInFilter:
+		if !c.optInEnabled {
+			return failFilter
+		}
+		return optInFilter
+	case NoPassFilter, *NoPassFilter:
+		return failFilter
+	case *UserFilter:
+		if filter.predicate != nil {
+			return filter.predicate
+		}
+		return compileUserFilter(filter)
+	case *CustomDataFilter:
+		if filter.predicate != nil {
+			return filter.predicate
+		}
+		return compileCustomDataFilter(filter)
+	case *AudienceMatchFilter:
+		if !c.standalone {
+			return c.compileAudienceMatchFilter(filter)
+		}
+		if filter.predicate != nil {
+			return filter.predicate
+		}
+		return compileStandaloneAudienceMatchFilter(filter)
+	default:
+		audiences := c.audiences
+		return func(ctx *filterContext) bool {
+			return filter.Evaluate(audiences, ctx.user, ctx.clientCustomData)
+		}
+	}
+}
+
+// optInFilter passes when the user opted in to the feature being segmented.
+func optInFilter(ctx *filterContext) bool {
+	return ctx.optIns != nil && ctx.featureId != "" && ctx.user.UserId != "" && ctx.optIns.IsOptedIn(ctx.user.UserId, ctx.featureId)
+}
+
+// compileStandaloneAudienceMatchFilter compiles an audience match filter that looks up its audiences in the
+// context, where Evaluate puts the audiences it is given.
+func compileStandaloneAudienceMatchFilter(filter *AudienceMatchFilter) filterPredicate {
+	audienceIds := filter.Audiences
+	comparator := filter.GetComparator()
+	return func(ctx *filterContext) bool {
+		for _, id := range audienceIds {
+			audience, ok := ctx.audiences[id]
+			if !ok || audience.Filters == nil {
+				return false
+			}
+			if standaloneCompiler.compileFilter(audience.Filters)(ctx) {
+				return comparator == ComparatorEqual
+			}
+		}
+		return comparator == ComparatorNotEqual
+	}
+}
+
+func (c *filterCompiler) compileAudienceMatchFilter(filter *AudienceMatchFilter) filterPredicate {
+	audienceIds := filter.Audiences
+	comparator := filter.GetComparator()
+	return func(ctx *filterContext) bool {
+		for _, id := range audienceIds {
+			audience := c.audience(id)
+			if audience == nil {
+				return false
+			}
+			if audience(ctx) {
+				return comparator == ComparatorEqual
+			}
+		}
+		return comparator == ComparatorNotEqual
+	}
+}
+
+func compileUserFilter(filter *UserFilter) filterPredicate {
+	if filter.invalid {
+		return failFilter
+	}
+	if isStringOnlyComparator(filter.GetComparator()) {
+		// Versions are matched as plain strings by these comparators
+		matches := compileStringMatcher(filter)
+		switch filter.SubType {
+		case SubTypeAppVersion:
+			return func(ctx *filterContext) bool { return matches(ctx.user.AppVersion) }
+		case SubTypePlatformVersion:
+			return func(ctx *filterContext) bool { return matches(ctx.user.PlatformVersion) }
+		}
+	}
+
+	switch filter.SubType {
+	case SubTypeIP:
+		matches := compileIPMatcher(filter.CompiledIPPrefixes, filter.GetComparator())
+		return func(ctx *filterContext) bool { return matches(ctx.user.IP) }
+	case SubTypeAppBuild:
+		matches := compileAppBuildMatcher(filter)
+		return func(ctx *filterContext) bool { return matches(ctx.user.AppBuild) }
+	case SubTypeAppVersion:
+		matches := compileVersionMatcher(filter.CompiledStringVals, filter.GetComparator())
+		return func(ctx *filterContext) bool { return matches(ctx.user.AppVersion) }
+	case SubTypePlatformVersion:
+		matches := compileVersionMatcher(filter.CompiledStringVals, filter.GetComparator())
+		return func(ctx *filterContext) bool { return matches(ctx.user.PlatformVersion) }
+	}
+
+	matches := compileStringMatcher(filter)
+	switch filter.SubType {
+	case SubTypeCountry:
+		return func(ctx *filterContext) bool { return matches(ctx.user.Country) }
+	case SubTypeEmail:
+		return func(ctx *filterContext) bool { return matches(ctx.user.Email) }
+	case SubTypeUserID:
+		return func(ctx *filterContext) bool { return matches(ctx.user.UserId) }
+	case SubTypeDeviceModel:
+		return func(ctx *filterContext) bool { return matches(ctx.user.User.DeviceModel) }
+	case SubTypePlatform:
+		return func(ctx *filterContext) bool { return matches(ctx.user.Platform) }
+	case SubTypeName:
+		return func(ctx *filterContext) bool { return matches(ctx.user.Name) }
+	case SubTypeLanguage:
+		return func(ctx *filterContext) bool { return matches(ctx.user.Language) }
+	}
+	return failFilter
+}
+
+// compileIPMatcher matches user IPs against addresses and CIDR ranges. = and != compare addresses, so
+// ranges only match with contain. IPs that don't parse are treated as missing.
+func compileIPMatcher(prefixes []netip.Prefix, comparator string) func(string) bool {
+	matchesAny := func(addr netip.Addr) bool {
+		for _, prefix := range prefixes {
+			if prefix.Contains(addr) && (prefix.IsSingleIP() || comparator == ComparatorContain || comparator == ComparatorNotContain) {
+				return true
+			}
+		}
+		return false
+	}
+
+	return func(ip string) bool {
+		addr, ok := parseUserIP(ip)
+		switch comparator {
+		case ComparatorExist:
+			return ok
+		case ComparatorNotExist:
+			return !ok
+		case ComparatorEqual, ComparatorContain:
+			return ok && matchesAny(addr)
+		case ComparatorNotEqual:
+			return ok && !matchesAny(addr)
+		case ComparatorNotContain:
+			return !ok || !matchesAny(addr)
+		}
+		return false
+	}
+}
+
+func parseUserIP(ip string) (netip.Addr, bool) {
+	if ip == "" {
+		return netip.Addr{}, false
+	}
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		return netip.Addr{}, false
+	}
+	return addr.Unmap().WithZone(""), true
+}
+
+// compileAppBuildMatcher compares app builds as numbers when both the build and the filter values are
+// numeric, and as strings otherwise. The ordering comparators only pass for numeric builds.
+func compileAppBuildMatcher(filter *UserFilter) func(string) bool {
+	comparator := filter.GetComparator()
+	matchesString := compileStringMatcher(filter)
+	switch comparator {
+	case ComparatorEqual, ComparatorNotEqual, ComparatorGreater, ComparatorGreaterEqual, ComparatorLess, ComparatorLessEqual:
+	default:
+		return matchesString
+	}
+
+	filterNums := filter.CompiledNumVals
+	if len(filterNums) == 0 && len(filter.CompiledStringVals) > 0 {
+		filterNums = make([]float64, 0, len(filter.CompiledStringVals))
+		for _, value := range filter.CompiledStringVals {
+			num, ok := parseAppBuild(value)
+			if !ok {
+				filterNums = nil
+				break
+			}
+			filterNums = append(filterNums, num)
+		}
+	}
+	if filterNums == nil {
+		return matchesString
+	}
+
+	matchesNumber := compileNumberMatcher(filterNums, comparator)
+	stringValues := len(filter.CompiledStringVals) > 0
+	return func(appBuild string) bool {
+		if num, ok := parseAppBuild(appBuild); ok {
+			return matchesNumber(num)
+		}
+		if stringValues {
+			return matchesString(appBuild)
+		}
+		return matchesNumber(math.NaN())
+	}
+}
+
+// parseAppBuild parses numeric app builds such as "42" or "1.5". It checks the characters first so that
+// non-numeric builds don't allocate a parse error.
+func parseAppBuild(appBuild string) (float64, bool) {
+	if appBuild == "" {
+		return 0, false
+	}
+	for i := 0; i < len(appBuild); i++ {
+		c := appBuild[i]
+		if (c < '0' || c > '9') && c != '.' && c != '-' && c != '+' && c != 'e' && c != 'E' {
+			return 0, false
+		}
+	}
+	num, err := strconv.ParseFloat(appBuild, 64)
+	if err != nil {
+		return 0, false
+	}
+	return num, true
+}
+
+func compileCustomDataFilter(filter *CustomDataFilter) filterPredicate {
+	if filter.invalid {
+		return failFilter
+	}
+	dataKey := filter.DataKey
+	comparator := filter.GetComparator()
+	switch comparator {
+	case ComparatorExist:
+		return func(ctx *filterContext) bool { return checkValueExists(ctx.customDataValue(dataKey)) }
+	case ComparatorNotExist:
+		return func(ctx *filterContext) bool { return !checkValueExists(ctx.customDataValue(dataKey)) }
+	}
+
+	// Values of the wrong type never match, but a missing value passes a != filter
+	missingPasses := comparator == ComparatorNotEqual
+	switch filter.DataKeyType {
+	case DataKeyTypeString:
+		matches := compileStringMatcher(filter.UserFilter)
+		return func(ctx *filterContext) bool {
+			value := ctx.customDataValue(dataKey)
+			if str, ok := value.(string); ok {
+				return matches(str)
+			}
+			return value == nil && missingPasses
+		}
+	case DataKeyTypeNumber:
+		matches := compileNumberMatcher(filter.CompiledNumVals, comparator)
+		return func(ctx *filterContext) bool {
+			value := ctx.customDataValue(dataKey)
+			if num, ok := value.(float64); ok {
+				return matches(num)
+			}
+			return value == nil && missingPasses
+		}
+	case DataKeyTypeBoolean:
+		matches := compileBooleanMatcher(filter.CompiledBoolVals, comparator)
+		return func(ctx *filterContext) bool {
+			value := ctx.customDataValue(dataKey)
+			if b, ok := value.(bool); ok {
+				return matches(b)
+			}
+			return value == nil && missingPasses
+		}
+	}
+	return func(ctx *filterContext) bool {
+		return missingPasses && ctx.customDataValue(dataKey) == nil
+	}
+}
+
+func compileStringMatcher(filter *UserFilter) func(string) bool {
+	if filter.invalid {
+		return func(string) bool { return false }
+	}
+	comparator, ignoreCase := splitIgnoreCase(filter.GetComparator())
+	values := filter.CompiledStringVals
+
+	var matchesAny func(string) bool
+	switch {
+	case ignoreCase || comparator == ComparatorRegex || comparator == ComparatorNotRegex:
+		regexes := filter.CompiledRegexes
+		matchesAny = func(str string) bool {
+			for _, regex := range regexes {
+				if regex.MatchString(str) {
+					return true
+				}
+			}
+			return false
+		}
+	case comparator == ComparatorEqual || comparator == ComparatorNotEqual:
+		set := newStringSet(values)
+		matchesAny = func(str string) bool {
+			_, ok := set[str]
+			return ok
+		}
+	case comparator == ComparatorContain || comparator == ComparatorNotContain:
+		matchesAny = func(str string) bool { return stringArrayContains(values, str) }
+	case comparator == ComparatorStartWith || comparator == ComparatorNotStartWith:
+		matchesAny = func(str string) bool {
+			for _, value := range values {
+				if strings.HasPrefix(str, value) {
+					return true
+				}
+			}
+			return false
+		}
+	case comparator == ComparatorEndWith || comparator == ComparatorNotEndWith:
+		matchesAny = func(str string) bool {
+			for _, value := range values {
+				if strings.HasSuffix(str, value) {
+					return true
+				}
+			}
+			return false
+		}
+	}
+
+	switch comparator {
+	case ComparatorEqual, ComparatorContain, ComparatorStartWith, ComparatorEndWith, ComparatorRegex:
+		return func(str string) bool { return str != "" && matchesAny(str) }
+	case ComparatorNotEqual:
+		return func(str string) bool { return str != "" && !matchesAny(str) }
+	case ComparatorNotContain, ComparatorNotStartWith, ComparatorNotEndWith, ComparatorNotRegex:
+		return func(str string) bool { return str == "" || !matchesAny(str) }
+	case ComparatorExist:
+		return func(str string) bool { return str != "" }
+	case ComparatorNotExist:
+		return func(str string) bool { return str == "" }
+	}
+	return func(string) bool { return false }
+}
+
+// isStringOnlyComparator reports whether the comparator only applies to strings, and not to versions or numbers.
+func isStringOnlyComparator(comparator string) bool {
+	comparator, ignoreCase := splitIgnoreCase(comparator)
+	switch comparator {
+	case ComparatorStartWith, ComparatorNotStartWith, ComparatorEndWith, ComparatorNotEndWith, ComparatorRegex, ComparatorNotRegex:
+		return true
+	}
+	return ignoreCase
+}
+
+func newStringSet(values []string) map[string]struct{} {
+	set := make(map[string]struct{}, len(values))
+	for _, value := range values {
+		set[value] = struct{}{}
+	}
+	return set
+}
+
+func compileBooleanMatcher(values []bool, comparator string) func(bool) bool {
+	hasTrue, hasFalse := false, false
+	for _, value := range values {
+		if value {
+			hasTrue = true
+		} else {
+			hasFalse = true
+		}
+	}
+	switch comparator {
+	case ComparatorContain, ComparatorEqual:
+		return func(b bool) bool { return (b && hasTrue) || (!b && hasFalse) }
+	case ComparatorNotContain, ComparatorNotEqual:
+		return func(b bool) bool { return !((b && hasTrue) || (!b && hasFalse)) }
+	case ComparatorExist:
+		return func(bool) bool { return true }
+	}
+	return func(bool) bool { return false }
+}
+
+// compileNumberMatcher reduces the filter values to a set or a bound, depending on the comparator.
+// NaN filter values never match, and make a != filter fail.
+func compileNumberMatcher(values []float64, comparator string) func(float64) bool {
+	switch comparator {
+	case ComparatorExist:
+		return func(num float64) bool { return !math.IsNaN(num) }
+	case ComparatorNotExist:
+		return math.IsNaN
+	}
+
+	set := make(map[float64]struct{}, len(values))
+	hasNaN := false
+	lowest, highest := math.Inf(1), math.Inf(-1)
+	for _, value := range values {
+		if math.IsNaN(value) {
+			hasNaN = true
+			continue
+		}
+		set[value] = struct{}{}
+		lowest = math.Min(lowest, value)
+		highest = math.Max(highest, value)
+	}
+	hasValues := len(set) > 0
+
+	var matches func(float64) bool
+	switch comparator {
+	case ComparatorEqual:
+		matches = func(num float64) bool {
+			_, ok := set[num]
+			return ok
+		}
+	case ComparatorNotEqual:
+		matches = func(num float64) bool {
+			_, ok := set[num]
+			return !hasNaN && !ok
+		}
+	case ComparatorGreater:
+		matches = func(num float64) bool { return hasValues && num > lowest }
+	case ComparatorGreaterEqual:
+		matches = func(num float64) bool { return hasValues && num >= lowest }
+	case ComparatorLess:
+		matches = func(num float64) bool { return hasValues && num < highest }
+	case ComparatorLessEqual:
+		matches = func(num float64) bool { return hasValues && num <= highest }
+	default:
+		return func(float64) bool { return false }
+	}
+	return func(num float64) bool {
+		return !math.IsNaN(num) && matches(num)
+	}
+}
+
+// Matches any non-number and . characters
+var versionCharactersRegex = regexp.MustCompile(`[^(\d|.|\-)]`)
+
+// cleanVersion removes everything after a hyphen, and any non-number and . characters,
+// eg. 1.2.3a-b6 becomes 1.2.3
+func cleanVersion(version string) string {
+	if i := strings.IndexByte(version, '-'); i >= 0 {
+		version = version[:i]
+	}
+	for i := 0; i < len(version); i++ {
+		switch c := version[i]; {
+		case c >= '0' && c <= '9', c == '.', c == '(', c == ')', c == '|':
+			continue
+		}
+		return versionCharactersRegex.ReplaceAllString(version, "")
+	}
+	return version
+}
+
+// compileVersionMatcher compiles the logic of checkVersionFilter. Filter versions are cleaned once, and
+// user versions are compared without building the semantic version.
+func compileVersionMatcher(filterVersions []string, operator string) func(string) bool {
+	not := operator == ComparatorNotEqual
+	clean := operator != ComparatorEqual && operator != ComparatorNotEqual
+
+	versions := make([]string, 0, len(filterVersions))
+	for _, filterVersion := range filterVersions {
+		if clean {
+			filterVersion = cleanVersion(filterVersion)
+		}
+		if filterVersion != "" {
+			versions = append(versions, filterVersion)
+		}
+	}
+
+	return func(version string) bool {
+		if version == "" {
+			return false
+		}
+		if clean {
+			version = cleanVersion(version)
+		}
+		passed := false
+		for _, filterVersion := range versions {
+			if versionComparisonPasses(compareVersions(version, filterVersion), operator) {
+				passed = true
+				break
+			}
+		}
+		return passed != not
+	}
+}
+
+func versionComparisonPasses(result float64, operator string) bool {
+	switch {
+	case math.IsNaN(result):
+		return false
+	case result == 0:
+		return strings.Contains(operator, "=")
+	case result == 1:
+		return strings.Contains(operator, ">")
+	case result == -1:
+		return strings.Contains(operator, "<")
+	}
+	return false
+}
+
+// compareVersions returns the same result as versionCompare(convertToSemanticVersion(version), filterVersion)
+// with ZeroExtend, without allocating: empty parts of version count as 0, and any part that is not a number
+// makes the versions incomparable.
+func compareVersions(version, filterVersion string) float64 {
+	if !isNumericVersion(version, true) || !isNumericVersion(filterVersion, false) {
+		return math.NaN()
+	}
+	for version != "" || filterVersion != "" {
+		var part, filterPart string
+		part, version, _ = strings.Cut(version, ".")
+		filterPart, filterVersion, _ = strings.Cut(filterVersion, ".")
+		value, filterValue := versionPartValue(part), versionPartValue(filterPart)
+		if value == filterValue {
+			continue
+		} else if value > filterValue {
+			return 1
+		}
+		return -1
+	}
+	return 0
+}
+
+func isNumericVersion(version string, allowEmptyParts bool) bool {
+	partLength := 0
+	for i := 0; i < len(version); i++ {
+		switch c := version[i]; {
+		case c >= '0' && c <= '9':
+			partLength++
+		case c == '.':
+			if partLength == 0 && !allowEmptyParts {
+				return false
+			}
+			partLength = 0
+		default:
+			return false
+		}
+	}
+	return allowEmptyParts || partLength > 0
+}
+
+func versionPartValue(part string) float64 {
+	if part == "" {
+		return 0
+	}
+	value, err := strconv.ParseFloat(part, 64)
+	if err != nil {
+		return math.NaN()
+	}
+	return value
+}
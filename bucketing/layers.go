@@ -0,0 +1,126 @@
+package bucketing
+
+import (
+	"errors"
+
+	"github.com/BIwashi/go-server-sdk/v2/api"
+	"github.com/BIwashi/go-server-sdk/v2/util"
+)
+
+var ErrUserLayerExcluded = errors.New("User is assigned to another experiment of the feature's layer")
+
+// FeatureTypeExperiment is the type of the features that layers and holdouts apply to.
+const FeatureTypeExperiment = "experiment"
+
+// controlVariationKey is the key of the variation served to held out users. Features without it serve their
+// first variation.
+const controlVariationKey = "control"
+
+// layerSlot is the range of layer hashes assigned to an experiment feature.
+type layerSlot struct {
+	layer *Layer
+	start float64
+	end   float64
+}
+
+// experimentDecision records how layers and holdouts decided the bucketing of a user for a feature.
+type experimentDecision struct {
+	// LayerId is the layer that assigned the user to the feature, or excluded them from it
+	LayerId string
+	// HoldoutId is the holdout that served the user the control variation
+	HoldoutId string
+}
+
+// compileExperiments maps the experiment features to their layer slots and holdouts.
+func (c *configBody) compileExperiments() {
+	c.layerSlots = nil
+	c.featureHoldouts = nil
+	experiments := make(map[string]bool)
+	for _, feature := range c.Features {
+		if feature.Type == FeatureTypeExperiment {
+			experiments[feature.Id] = true
+		}
+	}
+
+	for _, layer := range c.Layers {
+		start := 0.0
+		for _, experiment := range layer.Experiments {
+			end := start + experiment.Percentage
+			if !experiments[experiment.Feature] {
+				util.Warnf("Ignoring feature %s of layer %s: it is not an experiment feature", experiment.Feature, layer.Key)
+			} else if _, ok := c.layerSlots[experiment.Feature]; ok {
+				util.Warnf("Ignoring feature %s of layer %s: it is already in another layer", experiment.Feature, layer.Key)
+			} else {
+				if c.layerSlots == nil {
+					c.layerSlots = make(map[string]layerSlot)
+				}
+				c.layerSlots[experiment.Feature] = layerSlot{layer: layer, start: start, end: end}
+			}
+			start = end
+		}
+		if start > 1 {
+			util.Warnf("The experiments of layer %s add up to more than 100%%", layer.Key)
+		}
+	}
+
+	for _, holdout := range c.Holdouts {
+		featureIds := holdout.Features
+		if len(featureIds) == 0 {
+			featureIds = make([]string, 0, len(experiments))
+			for _, feature := range c.Features {
+				if experiments[feature.Id] {
+					featureIds = append(featureIds, feature.Id)
+				}
+			}
+		}
+		for _, featureId := range featureIds {
+			if !experiments[featureId] {
+				util.Warnf("Ignoring feature %s of holdout %s: it is not an experiment feature", featureId, holdout.Key)
+				continue
+			}
+			if c.featureHoldouts == nil {
+				c.featureHoldouts = make(map[string][]*Holdout)
+			}
+			c.featureHoldouts[featureId] = append(c.featureHoldouts[featureId], holdout)
+		}
+	}
+}
+
+// decideExperiment applies the holdouts and layer of the feature to the user. Layers and holdouts hash the value
+// of the engine's bucketing key, not of the target's, so that the decision is the same for every feature.
+func (c *configBody) decideExperiment(feature *ConfigFeature, user *api.PopulatedUser, inputs evaluationInputs) (experimentDecision, error) {
+	holdouts := c.featureHoldouts[feature.Id]
+	slot, inLayer := c.layerSlots[feature.Id]
+	if len(holdouts) == 0 && !inLayer {
+		return experimentDecision{}, nil
+	}
+
+	_, value := inputs.bucketingValue(user, &Target{})
+	for _, holdout := range holdouts {
+		if generateBoundedHash(value+"_holdout", murmurhashV3(holdout.Id, baseSeed)) < holdout.Percentage {
+			return experimentDecision{HoldoutId: holdout.Id}, nil
+		}
+	}
+	if !inLayer {
+		return experimentDecision{}, nil
+	}
+	decision := experimentDecision{LayerId: slot.layer.Id}
+	hash := generateBoundedHash(value+"_layer", murmurhashV3(slot.layer.Id, baseSeed))
+	if hash < slot.start || hash >= slot.end {
+		return decision, ErrUserLayerExcluded
+	}
+	return decision, nil
+}
+
+// controlVariation returns the variation served to users held out of the feature.
+func (f *ConfigFeature) controlVariation() (*Variation, error) {
+	for _, variation := range f.Variations {
+		if variation.Key == controlVariationKey {
+			return variation, nil
+		}
+	}
+	if len(f.Variations) == 0 {
+		return nil, ErrMissingVariation
+	}
+	return f.Variations[0], nil
+}
@@ -0,0 +1,182 @@
+package bucketing
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/BIwashi/go-server-sdk/v2/api"
+)
+
+// experimentFeature is a feature of experimentsConfig serving "<key>-var" to every user, with a 50/50 split
+// between its "control" and "treatment" variations.
+const experimentFeature = `{
+	"_id": "%[1]s-feature", "key": "%[1]s", "type": "%[2]s",
+	"variations": [
+		{"_id": "%[1]s-treatment", "key": "treatment", "name": "Treatment", "variables": [{"_var": "%[1]s-var-id", "value": "treatment"}]},
+		{"_id": "%[1]s-control", "key": "control", "name": "Control", "variables": [{"_var": "%[1]s-var-id", "value": "control"}]}
+	],
+	"configuration": {"_id": "%[1]s-configuration", "targets": [{
+		"_id": "%[1]s-target",
+		"_audience": {"_id": "%[1]s-audience", "filters": {"operator": "and", "filters": [{"type": "all"}]}},
+		"distribution": [{"_variation": "%[1]s-treatment", "percentage": 0.5}, {"_variation": "%[1]s-control", "percentage": 0.5}]
+	}]}
+}`
+
+// experimentsConfig has the "checkout" and "pricing" experiments in a 40/40 layer, the "search" experiment
+// outside of it, the "banner" release feature, and a 10% holdout.
+var experimentsConfig = fmt.Sprintf(`{
+	"project": {"_id": "project", "key": "project", "a0_organization": "org"},
+	"environment": {"_id": "environment", "key": "development"},
+	"features": [%s, %s, %s, %s],
+	"variables": [
+		{"_id": "checkout-var-id", "key": "checkout-var", "type": "String"},
+		{"_id": "pricing-var-id", "key": "pricing-var", "type": "String"},
+		{"_id": "search-var-id", "key": "search-var", "type": "String"},
+		{"_id": "banner-var-id", "key": "banner-var", "type": "String"}
+	],
+	"layers": [{"_id": "layer", "key": "funnel", "experiments": [
+		{"_feature": "checkout-feature", "percentage": 0.4},
+		{"_feature": "banner-feature", "percentage": 0.1},
+		{"_feature": "pricing-feature", "percentage": 0.4}
+	]}],
+	"holdouts": [{"_id": "holdout", "key": "global", "percentage": 0.1}]
+}`,
+	fmt.Sprintf(experimentFeature, "checkout", "experiment"),
+	fmt.Sprintf(experimentFeature, "pricing", "experiment"),
+	fmt.Sprintf(experimentFeature, "search", "experiment"),
+	fmt.Sprintf(experimentFeature, "banner", "release"),
+)
+
+func TestExperimentLayersAndHoldouts(t *testing.T) {
+	engine := newTestEngine(t, "engine-test-experiments")
+	require.NoError(t, engine.SetConfig([]byte(experimentsConfig), ""))
+	variableTypes := map[string]string{
+		"checkout-var": VariableTypesString,
+		"pricing-var":  VariableTypesString,
+		"search-var":   VariableTypesString,
+		"banner-var":   VariableTypesString,
+	}
+
+	const users = 5000
+	counts := map[string]int{}
+	for i := 0; i < users; i++ {
+		user := api.User{UserId: fmt.Sprintf("user-%d", i)}.GetPopulatedUser(&api.PlatformData{})
+		evaluations, err := engine.EvaluateVariables(user, variableTypes)
+		require.NoError(t, err)
+		checkout, pricing, search, banner := evaluations["checkout-var"], evaluations["pricing-var"], evaluations["search-var"], evaluations["banner-var"]
+
+		// Release features are not in layers or holdouts
+		require.Equal(t, api.EvalReasonTargetingMatch, banner.Reason)
+		require.Empty(t, banner.LayerId)
+		require.Empty(t, banner.HoldoutId)
+
+		if search.Reason == api.EvalReasonHoldout {
+			// Held out users get the control variation of every experiment
+			counts["holdout"]++
+			for _, evaluation := range []VariableEvaluation{checkout, pricing, search} {
+				require.Equal(t, api.EvalReasonHoldout, evaluation.Reason)
+				require.Equal(t, "holdout", evaluation.HoldoutId)
+				require.Equal(t, "control", evaluation.Value)
+				require.Equal(t, "control", evaluation.VariationKey)
+			}
+			continue
+		}
+		require.Equal(t, api.EvalReasonTargetingMatch, search.Reason)
+		require.Empty(t, search.LayerId)
+
+		// Users are in at most one experiment of the layer
+		require.Equal(t, "layer", checkout.LayerId)
+		require.Equal(t, "layer", pricing.LayerId)
+		inCheckout := checkout.Reason == api.EvalReasonTargetingMatch
+		inPricing := pricing.Reason == api.EvalReasonTargetingMatch
+		require.False(t, inCheckout && inPricing)
+		if inCheckout {
+			counts["checkout"]++
+		} else {
+			require.Equal(t, api.EvalReasonLayerExcluded, checkout.Reason)
+		}
+		if inPricing {
+			counts["pricing"]++
+		} else {
+			require.Equal(t, api.EvalReasonLayerExcluded, pricing.Reason)
+		}
+	}
+
+	require.InDelta(t, 0.1*users, counts["holdout"], 0.02*users)
+	require.InDelta(t, 0.9*0.4*users, counts["checkout"], 0.03*users)
+	require.InDelta(t, 0.9*0.4*users, counts["pricing"], 0.03*users)
+
+	// The bucketed config leaves out the experiments the user is not assigned to
+	for i := 0; i < users; i++ {
+		user := api.User{UserId: fmt.Sprintf("user-%d", i)}.GetPopulatedUser(&api.PlatformData{})
+		evaluation, _ := engine.EvaluateVariable(user, "checkout-var", VariableTypesString)
+		bucketedConfig, err := engine.GenerateBucketedConfig(user)
+		require.NoError(t, err)
+		feature, ok := bucketedConfig.Features["checkout"]
+		switch evaluation.Reason {
+		case api.EvalReasonLayerExcluded:
+			require.False(t, ok)
+		case api.EvalReasonHoldout:
+			require.Equal(t, "control", feature.VariationKey)
+			require.Equal(t, string(api.EvalReasonHoldout), feature.EvalReason)
+		default:
+			require.Equal(t, evaluation.VariationKey, feature.VariationKey)
+			require.Empty(t, feature.EvalReason)
+		}
+	}
+}
+
+func TestExperimentLayers_EvalDetails(t *testing.T) {
+	evaluation := VariableEvaluation{Reason: api.EvalReasonLayerExcluded, FeatureId: "feature", LayerId: "layer"}
+	require.Equal(t, api.EvalDetails{Reason: api.EvalReasonLayerExcluded, FeatureId: "feature", LayerId: "layer"}, evaluation.EvalDetails())
+	evaluation = VariableEvaluation{Reason: api.EvalReasonHoldout, FeatureId: "feature", HoldoutId: "holdout", VariationKey: "control"}
+	require.Equal(t, api.EvalDetails{Reason: api.EvalReasonHoldout, FeatureId: "feature", HoldoutId: "holdout", VariationKey: "control"}, evaluation.EvalDetails())
+}
+
+func TestEventQueue_ExperimentDecisionEvents(t *testing.T) {
+	eq, err := NewEventQueue("dvc_server_token_hash", &api.EventQueueOptions{FlushEventsInterval: time.Hour}, (&api.PlatformData{}).Default())
+	require.NoError(t, err)
+	defer eq.Close()
+
+	for i := 0; i < 2; i++ {
+		require.NoError(t, eq.processAggregateEvent(aggEventData{
+			eventType:   api.EventType_AggVariableEvaluated,
+			variableKey: "checkout-var",
+			featureId:   "checkout-feature",
+			variationId: "checkout-control",
+			decision:    experimentDecision{HoldoutId: "holdout"},
+		}))
+	}
+	require.NoError(t, eq.processAggregateEvent(aggEventData{
+		eventType:   api.EventType_AggVariableDefaulted,
+		variableKey: "pricing-var",
+		featureId:   "pricing-feature",
+		decision:    experimentDecision{LayerId: "layer"},
+	}))
+
+	payloads, err := eq.FlushEventQueue()
+	require.NoError(t, err)
+	var events []api.Event
+	for _, payload := range payloads {
+		for _, record := range payload.Records {
+			events = append(events, record.Events...)
+		}
+	}
+	require.Len(t, events, 2)
+	require.ElementsMatch(t, []map[string]interface{}{
+		{"_feature": "checkout-feature", "_variation": "checkout-control", "_holdout": "holdout"},
+		{"_feature": "pricing-feature", "_layer": "layer"},
+	}, []map[string]interface{}{events[0].MetaData, events[1].MetaData})
+	for _, event := range events {
+		if event.Target == "checkout-var" {
+			require.Equal(t, api.EventType_AggVariableEvaluated, event.Type_)
+			require.Equal(t, 2.0, event.Value)
+		} else {
+			require.Equal(t, api.EventType_AggVariableDefaulted, event.Type_)
+			require.Equal(t, 1.0, event.Value)
+		}
+	}
+}
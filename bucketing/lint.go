@@ -0,0 +1,303 @@
+package bucketing
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+)
+
+const (
+	// LintError is the severity of problems that make the config fail to load or evaluate as intended
+	LintError = "error"
+	// LintWarning is the severity of problems that leave parts of the config unused
+	LintWarning = "warning"
+)
+
+// distributionTolerance is how far the percentages of a distribution may add up from 1, for rounding errors.
+const distributionTolerance = 1e-9
+
+// LintIssue is a problem found in a config by LintConfig.
+type LintIssue struct {
+	Severity string `json:"severity"`
+	// Path of the config entry with the problem, such as "features[0].configuration.targets[1].distribution",
+	// or empty for problems with the whole config
+	Path    string `json:"path"`
+	Message string `json:"message"`
+}
+
+// lintConfigBody is the part of a config that is linted. Filters are kept as JSON, so that every filter is checked
+// even when one of them makes the config fail to parse.
+type lintConfigBody struct {
+	Audiences map[string]lintAudience `json:"audiences"`
+	Features  []struct {
+		Key           string       `json:"key"`
+		Variations    []*Variation `json:"variations"`
+		Configuration struct {
+			Targets []struct {
+				Id           string               `json:"_id"`
+				Audience     *lintAudience        `json:"_audience"`
+				Distribution []TargetDistribution `json:"distribution"`
+			} `json:"targets"`
+		} `json:"configuration"`
+	} `json:"features"`
+	Variables []*Variable `json:"variables"`
+}
+
+type lintAudience struct {
+	Filters json.RawMessage `json:"filters"`
+}
+
+// LintConfig checks a config the way the SDK loads it, and reports every problem found: errors loading the config,
+// distributions that don't add up to 1, audience matches of missing audiences or of audiences matching each other
+// in a cycle, variations setting unknown variables, variables set by no feature, and filters that never pass
+// because of an unsupported type, operator or comparator, values of mixed types, or customData filters without a
+// dataKey or with values not of their dataKeyType.
+func LintConfig(configJSON []byte) []LintIssue {
+	l := &linter{references: make(map[string][]string)}
+	if _, err := newConfig(configJSON, ""); err != nil {
+		l.errorf("", "config can't be loaded: %s", err)
+	}
+	var config lintConfigBody
+	if err := json.Unmarshal(configJSON, &config); err != nil {
+		return l.issues
+	}
+	l.audiences = config.Audiences
+
+	audienceIds := make([]string, 0, len(config.Audiences))
+	for id := range config.Audiences {
+		audienceIds = append(audienceIds, id)
+	}
+	sort.Strings(audienceIds)
+	for _, id := range audienceIds {
+		l.operator(fmt.Sprintf("audiences.%s.filters", id), config.Audiences[id].Filters, id)
+	}
+	l.audienceCycles(audienceIds)
+
+	variableIds := make(map[string]bool, len(config.Variables))
+	for _, variable := range config.Variables {
+		variableIds[variable.Id] = true
+	}
+	setVariableIds := make(map[string]bool)
+	for i, feature := range config.Features {
+		variationIds := make(map[string]bool, len(feature.Variations))
+		for j, variation := range feature.Variations {
+			variationIds[variation.Id] = true
+			for k, variable := range variation.Variables {
+				setVariableIds[variable.Var] = true
+				if !variableIds[variable.Var] {
+					l.errorf(fmt.Sprintf("features[%d].variations[%d].variables[%d]", i, j, k),
+						"variation %q of feature %q sets unknown variable %q", variation.Key, feature.Key, variable.Var)
+				}
+			}
+		}
+
+		for j, target := range feature.Configuration.Targets {
+			path := fmt.Sprintf("features[%d].configuration.targets[%d]", i, j)
+			if target.Audience == nil {
+				l.errorf(path+"._audience", "target %q has no audience, so it never matches", target.Id)
+			} else {
+				l.operator(path+"._audience.filters", target.Audience.Filters, "")
+			}
+
+			total := 0.0
+			for k, distribution := range target.Distribution {
+				total += distribution.Percentage
+				if !variationIds[distribution.Variation] {
+					l.errorf(fmt.Sprintf("%s.distribution[%d]", path, k),
+						"target %q distributes users to unknown variation %q", target.Id, distribution.Variation)
+				}
+			}
+			if math.Abs(total-1) > distributionTolerance {
+				l.errorf(path+".distribution", "distribution of target %q adds up to %v instead of 1", target.Id, total)
+			}
+		}
+	}
+
+	for i, variable := range config.Variables {
+		if !setVariableIds[variable.Id] {
+			l.warnf(fmt.Sprintf("variables[%d]", i), "variable %q is set by no feature, so it is always defaulted", variable.Key)
+		}
+	}
+	return l.issues
+}
+
+type linter struct {
+	audiences map[string]lintAudience
+	// references holds the audiences matched by the filters of each audience
+	references map[string][]string
+	issues     []LintIssue
+}
+
+func (l *linter) errorf(path string, format string, args ...interface{}) {
+	l.issues = append(l.issues, LintIssue{Severity: LintError, Path: path, Message: fmt.Sprintf(format, args...)})
+}
+
+func (l *linter) warnf(path string, format string, args ...interface{}) {
+	l.issues = append(l.issues, LintIssue{Severity: LintWarning, Path: path, Message: fmt.Sprintf(format, args...)})
+}
+
+// operator checks an operator and its nested filters. audienceId is the shared audience the filters belong to,
+// if any.
+func (l *linter) operator(path string, raw json.RawMessage, audienceId string) {
+	var operator struct {
+		Operator string            `json:"operator"`
+		Filters  []json.RawMessage `json:"filters"`
+	}
+	if err := json.Unmarshal(raw, &operator); err != nil {
+		l.errorf(path, "invalid operator: %s", err)
+		return
+	}
+	if operator.Operator != OperatorAnd && operator.Operator != OperatorOr {
+		l.errorf(path, "unsupported operator %q, the filters never pass", operator.Operator)
+	}
+	if len(operator.Filters) == 0 {
+		l.errorf(path, "operator has no filters, so it never passes")
+	}
+	for i, raw := range operator.Filters {
+		l.filter(fmt.Sprintf("%s.filters[%d]", path, i), raw, audienceId)
+	}
+}
+
+func (l *linter) filter(path string, raw json.RawMessage, audienceId string) {
+	var partial filter
+	if err := json.Unmarshal(raw, &partial); err != nil {
+		l.errorf(path, "invalid filter: %s", err)
+		return
+	}
+	if partial.Operator != "" {
+		l.operator(path, raw, audienceId)
+		return
+	}
+
+	switch partial.Type {
+	case TypeAll, TypeOptIn:
+	case TypeUser:
+		l.userFilter(path, raw, partial.SubType)
+	case TypeAudienceMatch:
+		var audienceMatch AudienceMatchFilter
+		if err := json.Unmarshal(raw, &audienceMatch); err != nil {
+			l.errorf(path, "invalid audience match filter: %s", err)
+			return
+		}
+		comparator := audienceMatch.GetComparator()
+		if comparator != ComparatorEqual && comparator != ComparatorNotEqual {
+			l.errorf(path, "unsupported comparator %q for audience match filters, the filter never passes", comparator)
+		}
+		for _, id := range audienceMatch.Audiences {
+			if _, ok := l.audiences[id]; !ok {
+				l.errorf(path, "audience match filter references unknown audience %q", id)
+			}
+			if audienceId != "" && !containsString(l.references[audienceId], id) {
+				l.references[audienceId] = append(l.references[audienceId], id)
+			}
+		}
+	default:
+		l.errorf(path, "unsupported filter type %q, the filter never passes", partial.Type)
+	}
+}
+
+var userFilterSubTypes = map[string]bool{
+	SubTypeUserID: true, SubTypeEmail: true, SubTypeIP: true, SubTypeCountry: true, SubTypePlatform: true,
+	SubTypePlatformVersion: true, SubTypeAppVersion: true, SubTypeDeviceModel: true, SubTypeName: true,
+	SubTypeLanguage: true, SubTypeAppBuild: true, SubTypeCustomData: true,
+}
+
+func (l *linter) userFilter(path string, raw json.RawMessage, subType string) {
+	if !userFilterSubTypes[subType] {
+		l.errorf(path, "unsupported user filter subType %q, the filter never passes", subType)
+		return
+	}
+	customDataFilter := &CustomDataFilter{UserFilter: &UserFilter{}}
+	var err error
+	if subType == SubTypeCustomData {
+		err = json.Unmarshal(raw, customDataFilter)
+	} else {
+		err = json.Unmarshal(raw, customDataFilter.UserFilter)
+	}
+	if err != nil {
+		l.errorf(path, "invalid %s filter: %s", subType, err)
+		return
+	}
+	userFilter := customDataFilter.UserFilter
+	if subType == SubTypeCustomData && customDataFilter.DataKey == "" {
+		l.errorf(path, "customData filter has no dataKey, the filter never passes")
+	}
+	comparator, _ := splitIgnoreCase(userFilter.Comparator)
+	if !stringComparators[comparator] && !otherComparators[comparator] {
+		l.errorf(path, "unsupported comparator %q for %s filters, the filter never passes", userFilter.Comparator, subType)
+		return
+	}
+	if err := userFilter.Initialize(); err != nil {
+		l.errorf(path, "invalid values for %s filter: %s", subType, strings.TrimSpace(err.Error()))
+	} else if userFilter.invalid {
+		l.errorf(path, "values of %s filter are invalid for the %q comparator, the filter never passes", subType, userFilter.Comparator)
+	} else if subType == SubTypeCustomData && comparator != ComparatorExist && comparator != ComparatorNotExist {
+		l.customDataValues(path, customDataFilter)
+	}
+}
+
+// customDataValues reports customData filters whose values don't have their dataKeyType, which never match the
+// custom data.
+func (l *linter) customDataValues(path string, filter *CustomDataFilter) {
+	var valuesMatch bool
+	switch filter.DataKeyType {
+	case DataKeyTypeString:
+		valuesMatch = filter.CompiledStringVals != nil
+	case DataKeyTypeNumber:
+		valuesMatch = filter.CompiledNumVals != nil
+	case DataKeyTypeBoolean:
+		valuesMatch = filter.CompiledBoolVals != nil
+	default:
+		l.errorf(path, "unsupported dataKeyType %q for customData filters, the filter never passes", filter.DataKeyType)
+		return
+	}
+	if !valuesMatch && len(filter.Values) > 0 {
+		l.errorf(path, "values of customData filter are not of its %s dataKeyType, the filter never passes", filter.DataKeyType)
+	}
+}
+
+// audienceCycles reports audiences that match each other in a cycle, whose evaluation never finishes.
+func (l *linter) audienceCycles(audienceIds []string) {
+	const (
+		visiting = 1
+		visited  = 2
+	)
+	state := make(map[string]int, len(audienceIds))
+	var stack []string
+	var visit func(id string)
+	visit = func(id string) {
+		state[id] = visiting
+		stack = append(stack, id)
+		for _, reference := range l.references[id] {
+			switch state[reference] {
+			case 0:
+				visit(reference)
+			case visiting:
+				start := len(stack) - 1
+				for stack[start] != reference {
+					start--
+				}
+				cycle := append(append([]string{}, stack[start:]...), reference)
+				l.errorf(fmt.Sprintf("audiences.%s.filters", id), "audiences match each other in a cycle: %s", strings.Join(cycle, " -> "))
+			}
+		}
+		stack = stack[:len(stack)-1]
+		state[id] = visited
+	}
+	for _, id := range audienceIds {
+		if state[id] == 0 {
+			visit(id)
+		}
+	}
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,104 @@
+package bucketing
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// lintedConfig has one of each problem LintConfig reports.
+const lintedConfig = `{
+	"project": {"_id": "project", "key": "project", "a0_organization": "org"},
+	"environment": {"_id": "environment", "key": "development"},
+	"audiences": {
+		"a": {"filters": {"operator": "and", "filters": [{"type": "audienceMatch", "comparator": "=", "_audiences": ["b"]}]}},
+		"b": {"filters": {"operator": "or", "filters": [
+			{"type": "audienceMatch", "comparator": "=", "_audiences": ["a", "missing"]}
+		]}}
+	},
+	"features": [{
+		"_id": "feature", "key": "feature", "type": "release",
+		"variations": [{"_id": "on", "key": "on", "name": "On", "variables": [{"_var": "unknown-var", "value": true}]}],
+		"configuration": {"_id": "configuration", "targets": [{
+			"_id": "target",
+			"_audience": {"_id": "audience", "filters": {"operator": "and", "filters": [
+				{"type": "geo", "comparator": "="},
+				{"type": "user", "subType": "email", "comparator": "between", "values": ["a"]},
+				{"type": "user", "subType": "country", "comparator": "=", "values": ["CA", 1]},
+				{"operator": "xor", "filters": [{"type": "all"}]}
+			]}},
+			"distribution": [{"_variation": "on", "percentage": 0.5}, {"_variation": "off", "percentage": 0.4}]
+		}]}
+	}],
+	"variables": [{"_id": "unused-var", "key": "unused", "type": "Boolean"}]
+}`
+
+func TestLintConfig(t *testing.T) {
+	require.Empty(t, LintConfig(test_config))
+
+	issues := LintConfig([]byte(lintedConfig))
+	paths := make([]string, len(issues))
+	for i, issue := range issues {
+		paths[i] = issue.Severity + " " + issue.Path
+	}
+	target := "features[0].configuration.targets[0]"
+	require.Equal(t, []string{
+		// Values of mixed types fail the whole config
+		"error ",
+		"error audiences.b.filters.filters[0]",
+		"error audiences.b.filters",
+		"error features[0].variations[0].variables[0]",
+		"error " + target + "._audience.filters.filters[0]",
+		"error " + target + "._audience.filters.filters[1]",
+		"error " + target + "._audience.filters.filters[2]",
+		"error " + target + "._audience.filters.filters[3]",
+		"error " + target + ".distribution[1]",
+		"error " + target + ".distribution",
+		"warning variables[0]",
+	}, paths)
+	require.Contains(t, issues[0].Message, "Filter values must be all of the same type")
+	require.Equal(t, `audience match filter references unknown audience "missing"`, issues[1].Message)
+	require.Equal(t, "audiences match each other in a cycle: a -> b -> a", issues[2].Message)
+	require.Equal(t, `unsupported filter type "geo", the filter never passes`, issues[4].Message)
+	require.Equal(t, `unsupported comparator "between" for email filters, the filter never passes`, issues[5].Message)
+	require.Contains(t, issues[6].Message, "Expected: string")
+	require.Equal(t, `unsupported operator "xor", the filters never pass`, issues[7].Message)
+	require.Equal(t, `distribution of target "target" adds up to 0.9 instead of 1`, issues[9].Message)
+}
+
+func TestLintConfig_InvalidJSON(t *testing.T) {
+	issues := LintConfig([]byte(`{"features": [`))
+	require.Len(t, issues, 1)
+	require.Equal(t, LintError, issues[0].Severity)
+}
+
+func TestLintConfig_CustomDataFilters(t *testing.T) {
+	config := `{
+	"project": {"_id": "project", "key": "project", "a0_organization": "org"},
+	"environment": {"_id": "environment", "key": "development"},
+	"audiences": {"custom": {"filters": {"operator": "and", "filters": [
+		{"type": "user", "subType": "customData", "dataKey": "plan", "dataKeyType": "String", "comparator": "=", "values": ["pro"]},
+		{"type": "user", "subType": "customData", "dataKey": "seats", "dataKeyType": "Number", "comparator": ">", "values": [3]},
+		{"type": "user", "subType": "customData", "dataKey": "beta", "dataKeyType": "Boolean", "comparator": "exist", "values": []},
+		{"type": "user", "subType": "customData", "dataKeyType": "String", "comparator": "=", "values": ["pro"]},
+		{"type": "user", "subType": "customData", "dataKey": "seats", "dataKeyType": "Number", "comparator": "=", "values": ["3"]},
+		{"type": "user", "subType": "customData", "dataKey": "beta", "dataKeyType": "Boolean", "comparator": "=", "values": [1]},
+		{"type": "user", "subType": "customData", "dataKey": "plan", "dataKeyType": "Date", "comparator": "=", "values": ["pro"]}
+	]}}},
+	"features": [],
+	"variables": []
+}`
+	var messages []string
+	for _, issue := range LintConfig([]byte(config)) {
+		if issue.Path != "" {
+			messages = append(messages, issue.Path+": "+issue.Message)
+		}
+	}
+	path := "audiences.custom.filters.filters"
+	require.Equal(t, []string{
+		path + "[3]: customData filter has no dataKey, the filter never passes",
+		path + "[4]: values of customData filter are not of its Number dataKeyType, the filter never passes",
+		path + "[5]: values of customData filter are not of its Boolean dataKeyType, the filter never passes",
+		path + `[6]: unsupported dataKeyType "Date" for customData filters, the filter never passes`,
+	}, messages)
+}
@@ -1,14 +1,15 @@
 package bucketing
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
-	"reflect"
 	"sort"
 
 	"github.com/go-playground/validator/v10"
 
 	"github.com/BIwashi/go-server-sdk/v2/api"
+	"github.com/BIwashi/go-server-sdk/v2/util"
 )
 
 // use a single instance of Validate, it caches struct info
@@ -30,10 +31,15 @@ type configBody struct {
 	Environment            api.Environment         `json:"environment" validate:"required"`
 	Features               []*ConfigFeature        `json:"features" validate:"required"`
 	Variables              []*Variable             `json:"variables" validate:"required,dive"`
+	Layers                 []*Layer                `json:"layers,omitempty" validate:"dive"`
+	Holdouts               []*Holdout              `json:"holdouts,omitempty" validate:"dive"`
 	etag                   string                  // TODO: remove etag
 	variableIdMap          map[string]*Variable
 	variableKeyMap         map[string]*Variable
 	variableIdToFeatureMap map[string]*ConfigFeature
+	targetFilters          map[*Target]filterPredicate
+	layerSlots             map[string]layerSlot
+	featureHoldouts        map[string][]*Holdout
 }
 
 func newConfig(configJSON []byte, etag string) (*configBody, error) {
@@ -65,6 +71,16 @@ func (c *configBody) GetVariableForId(id string) *Variable {
 	return nil
 }
 
+// GetFeatureForKey returns the feature with the key, or nil if there is none.
+func (c *configBody) GetFeatureForKey(key string) *ConfigFeature {
+	for _, feature := range c.Features {
+		if feature.Key == key {
+			return feature
+		}
+	}
+	return nil
+}
+
 func (c *configBody) GetFeatureForVariableId(id string) *ConfigFeature {
 	if feature, ok := c.variableIdToFeatureMap[id]; ok {
 		return feature
@@ -97,6 +113,20 @@ func (c *configBody) compile(etag string) {
 	c.variableKeyMap = variableKeyMap
 	c.etag = etag
 
+	// Compile the audience filters of every target, so evaluations don't walk the parsed filters
+	compiler := c.newFilterCompiler()
+	compiler.compileAudiences()
+	for _, feature := range c.Features {
+		for _, target := range feature.Configuration.Targets {
+			if c.targetFilters == nil {
+				c.targetFilters = make(map[*Target]filterPredicate)
+			}
+			c.targetFilters[target] = compiler.compileTarget(target)
+		}
+	}
+
+	c.compileExperiments()
+
 	// Sort the feature distributions by "_variation" attribute in descending alphabetical order
 	for _, feature := range c.Features {
 		for _, target := range feature.Configuration.Targets {
@@ -107,6 +137,29 @@ func (c *configBody) compile(etag string) {
 	}
 }
 
+// targetFilter returns the compiled audience filters of the target.
+func (c *configBody) targetFilter(target *Target) filterPredicate {
+	if predicate, ok := c.targetFilters[target]; ok {
+		return predicate
+	}
+	// Targets are only compiled when the config is set, so one added later never matches
+	util.Warnf("Target %s was not compiled with the config, so it is skipped", target.Id)
+	return failFilter
+}
+
+func (c *configBody) newFilterCompiler() *filterCompiler {
+	compiler := newFilterCompiler(c.Audiences)
+	compiler.optInEnabled = c.Project.Settings.OptIn.Enabled
+	return compiler
+}
+
+// Equals compares the parsed configs. Compiled filters are functions, which are never deeply equal, so the
+// configs are compared as JSON.
 func (c *configBody) Equals(c2 configBody) bool {
-	return reflect.DeepEqual(*c, c2)
+	if c.etag != c2.etag {
+		return false
+	}
+	json1, err1 := json.Marshal(c)
+	json2, err2 := json.Marshal(&c2)
+	return err1 == nil && err2 == nil && bytes.Equal(json1, json2)
 }
@@ -142,3 +142,14 @@ func TestParseConfigBody(t *testing.T) {
 		})
 	}
 }
+
+func TestConfigBody_TargetFilterFailsClosed(t *testing.T) {
+	config, err := newConfig(test_config, "")
+	require.NoError(t, err)
+
+	// A target added after the config was compiled is never matched, rather than compiled per evaluation
+	all := &AudienceOperator{Operator: OperatorAnd, Filters: MixedFilters{&AllFilter{}}}
+	require.NoError(t, all.Initialize())
+	target := &Target{Id: "late-target", Audience: &Audience{NoIdAudience: NoIdAudience{Filters: all}}}
+	require.False(t, evaluateFilter(config.targetFilter(target), nil, brooks, nil))
+}
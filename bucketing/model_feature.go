@@ -17,9 +17,9 @@ type Variation struct {
 }
 
 func (v *Variation) GetVariableById(id string) *VariationVariable {
-	for _, variable := range v.Variables {
-		if variable.Var == id {
-			return &variable
+	for i := range v.Variables {
+		if v.Variables[i].Var == id {
+			return &v.Variables[i]
 		}
 	}
 	return nil
@@ -3,6 +3,9 @@ package bucketing
 import (
 	"encoding/json"
 	"fmt"
+	"net/netip"
+	"regexp"
+	"strings"
 
 	"github.com/BIwashi/go-server-sdk/v2/api"
 	"github.com/BIwashi/go-server-sdk/v2/util"
@@ -21,8 +24,8 @@ type InitializedFilter interface {
 // Represents a partially parsed filter object from the JSON, before parsing a specific filter type
 type filter struct {
 	Type       string `json:"type" validate:"regexp=^(all|user|optIn)$"`
-	SubType    string `json:"subType" validate:"regexp=^(|user_id|email|ip|country|platform|platformVersion|appVersion|deviceModel|customData)$"`
-	Comparator string `json:"comparator" validate:"regexp=^(=|!=|>|>=|<|<=|exist|!exist|contain|!contain)$"`
+	SubType    string `json:"subType" validate:"regexp=^(|user_id|email|ip|country|platform|platformVersion|appVersion|deviceModel|name|language|appBuild|customData)$"`
+	Comparator string `json:"comparator" validate:"regexp=^(>|>=|<|<=|exist|!exist|(=|!=|contain|!contain|startWith|!startWith|endWith|!endWith|regex|!regex)(IgnoreCase)?)$"`
 	Operator   string `json:"operator" validate:"regexp=^(and|or)$"`
 }
 
@@ -126,7 +129,8 @@ type AllFilter struct {
 	PassFilter
 }
 
-// OptInFilter never passes, because it's only supported by Cloud Bucketing.
+// OptInFilter passes for users that opted in to the feature being segmented, when opt-in is enabled in the
+// project settings. Evaluated on its own, without a feature or an opt-in store, it never passes.
 type OptInFilter struct {
 	NoPassFilter
 }
@@ -138,6 +142,15 @@ type UserFilter struct {
 	CompiledStringVals []string
 	CompiledBoolVals   []bool
 	CompiledNumVals    []float64
+	// Patterns of the regex and case-insensitive comparators
+	CompiledRegexes []*regexp.Regexp
+	// Addresses and CIDR ranges of ip filters
+	CompiledIPPrefixes []netip.Prefix
+
+	// invalid is set when the filter can't be evaluated, for example because of an unknown comparator
+	invalid bool
+	// predicate is compiled by Initialize and run by Evaluate
+	predicate filterPredicate
 }
 
 func (filter *UserFilter) Evaluate(audiences map[string]NoIdAudience, user api.PopulatedUser, clientCustomData map[string]interface{}) bool {
@@ -149,7 +162,131 @@ func (f UserFilter) Type() string {
 }
 
 func (f *UserFilter) Initialize() error {
-	return f.compileValues()
+	if err := f.compileValues(); err != nil {
+		return err
+	}
+	f.compileComparator()
+	if f.SubType == SubTypeIP && !f.invalid {
+		f.compileIPPrefixes()
+	}
+	f.predicate = compileUserFilter(f)
+	return nil
+}
+
+// compileIPPrefixes parses the IP addresses and CIDR ranges of an ip filter. Single addresses become
+// prefixes covering only that address.
+func (f *UserFilter) compileIPPrefixes() {
+	switch f.Comparator {
+	case ComparatorEqual, ComparatorNotEqual, ComparatorContain, ComparatorNotContain, ComparatorExist, ComparatorNotExist:
+	default:
+		util.Warnf("Warning: The %s comparator is not supported by ip filters, the filter will not pass", f.Comparator)
+		f.invalid = true
+		return
+	}
+
+	prefixes := make([]netip.Prefix, 0, len(f.CompiledStringVals))
+	for _, value := range f.CompiledStringVals {
+		prefix, err := parseIPPrefix(value)
+		if err != nil {
+			util.Warnf("Warning: Invalid IP address or CIDR range in ip filter, the filter will not pass: %s", err)
+			f.invalid = true
+			return
+		}
+		prefixes = append(prefixes, prefix)
+	}
+	f.CompiledIPPrefixes = prefixes
+}
+
+func parseIPPrefix(value string) (netip.Prefix, error) {
+	if strings.Contains(value, "/") {
+		prefix, err := netip.ParsePrefix(value)
+		if err != nil {
+			return netip.Prefix{}, err
+		}
+		if prefix.Addr().Is4In6() && prefix.Bits() >= 96 {
+			prefix = netip.PrefixFrom(prefix.Addr().Unmap(), prefix.Bits()-96)
+		}
+		return prefix.Masked(), nil
+	}
+	addr, err := netip.ParseAddr(value)
+	if err != nil {
+		return netip.Prefix{}, err
+	}
+	addr = addr.Unmap().WithZone("")
+	return netip.PrefixFrom(addr, addr.BitLen()), nil
+}
+
+// compileComparator checks the comparator and compiles the patterns it needs. Like filters of an unknown type,
+// filters that can't be evaluated are kept but never pass.
+func (f *UserFilter) compileComparator() {
+	comparator, ignoreCase := splitIgnoreCase(f.Comparator)
+	if !stringComparators[comparator] && !otherComparators[comparator] {
+		util.Warnf(`Warning: Invalid filter comparator %s. To leverage this new filter definition, please update to the latest version of the DevCycle SDK.`, f.Comparator)
+		f.invalid = true
+		return
+	}
+
+	var patterns []string
+	switch {
+	case comparator == ComparatorRegex || comparator == ComparatorNotRegex:
+		patterns = f.CompiledStringVals
+	case ignoreCase && len(f.CompiledStringVals) > 0:
+		quoted := make([]string, len(f.CompiledStringVals))
+		for i, value := range f.CompiledStringVals {
+			quoted[i] = regexp.QuoteMeta(value)
+		}
+		pattern := "(?:" + strings.Join(quoted, "|") + ")"
+		switch comparator {
+		case ComparatorEqual, ComparatorNotEqual:
+			pattern = "^" + pattern + "$"
+		case ComparatorStartWith, ComparatorNotStartWith:
+			pattern = "^" + pattern
+		case ComparatorEndWith, ComparatorNotEndWith:
+			pattern = pattern + "$"
+		}
+		patterns = []string{pattern}
+	}
+
+	regexes := make([]*regexp.Regexp, 0, len(patterns))
+	for _, pattern := range patterns {
+		if ignoreCase {
+			pattern = "(?i)" + pattern
+		}
+		regex, err := regexp.Compile(pattern)
+		if err != nil {
+			util.Warnf("Warning: Invalid regex in %s filter, the filter will not pass: %s", f.Comparator, err)
+			f.invalid = true
+			return
+		}
+		regexes = append(regexes, regex)
+	}
+	if len(regexes) > 0 {
+		f.CompiledRegexes = regexes
+	}
+}
+
+// stringComparators can be made case-insensitive with ComparatorIgnoreCaseSuffix.
+var stringComparators = map[string]bool{
+	ComparatorEqual: true, ComparatorNotEqual: true,
+	ComparatorContain: true, ComparatorNotContain: true,
+	ComparatorStartWith: true, ComparatorNotStartWith: true,
+	ComparatorEndWith: true, ComparatorNotEndWith: true,
+	ComparatorRegex: true, ComparatorNotRegex: true,
+}
+
+var otherComparators = map[string]bool{
+	ComparatorGreater: true, ComparatorGreaterEqual: true,
+	ComparatorLess: true, ComparatorLessEqual: true,
+	ComparatorExist: true, ComparatorNotExist: true,
+}
+
+// splitIgnoreCase returns the comparator without ComparatorIgnoreCaseSuffix, and whether it was case-insensitive.
+func splitIgnoreCase(comparator string) (string, bool) {
+	base := strings.TrimSuffix(comparator, ComparatorIgnoreCaseSuffix)
+	if base != comparator && stringComparators[base] {
+		return base, true
+	}
+	return comparator, false
 }
 
 func (u *UserFilter) compileValues() error {
@@ -200,10 +337,21 @@ type CustomDataFilter struct {
 	*UserFilter
 	DataKey     string `json:"dataKey"`
 	DataKeyType string `json:"dataKeyType" validate:"regexp=^(String|Boolean|Number)$"`
+
+	// predicate is compiled by Initialize and run by Evaluate
+	predicate filterPredicate
 }
 
 func (filter *CustomDataFilter) Evaluate(audiences map[string]NoIdAudience, user api.PopulatedUser, clientCustomData map[string]interface{}) bool {
-	return checkCustomData(filter, user.CombinedCustomData(), clientCustomData)
+	return evaluateFilter(standaloneCompiler.compileFilter(filter), nil, user, clientCustomData)
+}
+
+func (f *CustomDataFilter) Initialize() error {
+	if err := f.UserFilter.Initialize(); err != nil {
+		return err
+	}
+	f.predicate = compileCustomDataFilter(f)
+	return nil
 }
 
 func (f CustomDataFilter) Type() string {
@@ -217,6 +365,9 @@ func (f CustomDataFilter) SubType() string {
 type AudienceMatchFilter struct {
 	filter
 	Audiences []string `json:"_audiences"`
+
+	// predicate is compiled by Initialize and run by Evaluate
+	predicate filterPredicate
 }
 
 func (filter *AudienceMatchFilter) Evaluate(audiences map[string]NoIdAudience, user api.PopulatedUser, clientCustomData map[string]interface{}) bool {
@@ -226,3 +377,8 @@ func (filter *AudienceMatchFilter) Evaluate(audiences map[string]NoIdAudience, u
 func (f AudienceMatchFilter) Type() string {
 	return TypeAudienceMatch
 }
+
+func (f *AudienceMatchFilter) Initialize() error {
+	f.predicate = compileStandaloneAudienceMatchFilter(f)
+	return nil
+}
@@ -1,6 +1,7 @@
 package bucketing
 
 import (
+	"encoding/json"
 	"testing"
 
 	"github.com/stretchr/testify/require"
@@ -131,6 +132,96 @@ func TestCheckStringsFilter(t *testing.T) {
 	}
 }
 
+func TestCheckStringsFilter_PatternComparators(t *testing.T) {
+	tests := []struct {
+		name       string
+		comparator string
+		values     []interface{}
+		subject    string
+		expected   bool
+	}{
+		{"startWith_match", ComparatorStartWith, []interface{}{"usr_", "svc_"}, "svc_1234", true},
+		{"startWith_nomatch", ComparatorStartWith, []interface{}{"usr_"}, "USR_1234", false},
+		{"startWith_empty", ComparatorStartWith, []interface{}{""}, "", false},
+		{"!startWith_match", ComparatorNotStartWith, []interface{}{"usr_"}, "usr_1234", false},
+		{"!startWith_nomatch", ComparatorNotStartWith, []interface{}{"usr_"}, "svc_1234", true},
+		{"!startWith_empty", ComparatorNotStartWith, []interface{}{"usr_"}, "", true},
+
+		{"endWith_match", ComparatorEndWith, []interface{}{"@devcycle.com", "@taplytics.com"}, "jane@taplytics.com", true},
+		{"endWith_nomatch", ComparatorEndWith, []interface{}{"@devcycle.com"}, "jane@devcycle.com.evil", false},
+		{"!endWith_match", ComparatorNotEndWith, []interface{}{"@devcycle.com"}, "jane@devcycle.com", false},
+		{"!endWith_nomatch", ComparatorNotEndWith, []interface{}{"@devcycle.com"}, "jane@example.com", true},
+
+		{"regex_match", ComparatorRegex, []interface{}{`^\d{3}-\d{4}$`}, "555-1234", true},
+		{"regex_match any", ComparatorRegex, []interface{}{`^a`, `z$`}, "fizz", true},
+		{"regex_nomatch", ComparatorRegex, []interface{}{`^\d{3}-\d{4}$`}, "555-12345", false},
+		{"regex_empty", ComparatorRegex, []interface{}{`.*`}, "", false},
+		{"!regex_match", ComparatorNotRegex, []interface{}{`^test-`}, "test-user", false},
+		{"!regex_nomatch", ComparatorNotRegex, []interface{}{`^test-`}, "real-user", true},
+
+		{"=IgnoreCase_match", ComparatorEqual + ComparatorIgnoreCaseSuffix, []interface{}{"Canada", "Mexico"}, "CANADA", true},
+		{"=IgnoreCase_partial", ComparatorEqual + ComparatorIgnoreCaseSuffix, []interface{}{"Canada"}, "Canadas", false},
+		{"=IgnoreCase_special characters", ComparatorEqual + ComparatorIgnoreCaseSuffix, []interface{}{"a.b"}, "axb", false},
+		{"!=IgnoreCase_match", ComparatorNotEqual + ComparatorIgnoreCaseSuffix, []interface{}{"Canada"}, "canada", false},
+		{"!=IgnoreCase_empty", ComparatorNotEqual + ComparatorIgnoreCaseSuffix, []interface{}{"Canada"}, "", false},
+		{"containIgnoreCase_match", ComparatorContain + ComparatorIgnoreCaseSuffix, []interface{}{"chrome"}, "Google Chrome", true},
+		{"!containIgnoreCase_match", ComparatorNotContain + ComparatorIgnoreCaseSuffix, []interface{}{"chrome"}, "Google Chrome", false},
+		{"startWithIgnoreCase_match", ComparatorStartWith + ComparatorIgnoreCaseSuffix, []interface{}{"usr_"}, "USR_1234", true},
+		{"endWithIgnoreCase_match", ComparatorEndWith + ComparatorIgnoreCaseSuffix, []interface{}{"@DevCycle.com"}, "jane@devcycle.COM", true},
+		{"!endWithIgnoreCase_match", ComparatorNotEndWith + ComparatorIgnoreCaseSuffix, []interface{}{"@devcycle.com"}, "jane@DEVCYCLE.com", false},
+		{"regexIgnoreCase_match", ComparatorRegex + ComparatorIgnoreCaseSuffix, []interface{}{`^beta-`}, "BETA-tester", true},
+		{"!regexIgnoreCase_match", ComparatorNotRegex + ComparatorIgnoreCaseSuffix, []interface{}{`^beta-`}, "Beta-tester", false},
+
+		// Unknown comparators and invalid patterns fail closed, even when negated
+		{"unknown comparator", "sortOf", []interface{}{"foo"}, "foo", false},
+		{"ignore case on a number comparator", ComparatorGreater + ComparatorIgnoreCaseSuffix, []interface{}{"foo"}, "foo", false},
+		{"invalid regex", ComparatorRegex, []interface{}{`(`}, "(", false},
+		{"invalid negated regex", ComparatorNotRegex, []interface{}{`(`}, "foo", false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			filter := &UserFilter{
+				filter: filter{
+					Type:       TypeUser,
+					SubType:    SubTypeEmail,
+					Comparator: test.comparator,
+				},
+				Values: test.values,
+			}
+			require.NoError(t, filter.Initialize())
+			require.Equal(t, test.expected, checkStringsFilter(test.subject, filter))
+
+			user := brooks
+			user.Email = test.subject
+			require.Equal(t, test.expected, filter.Evaluate(nil, user, nil))
+		})
+	}
+}
+
+func TestCheckCustomData_PatternComparators(t *testing.T) {
+	var filters MixedFilters
+	err := json.Unmarshal([]byte(`[
+		{"type": "user", "subType": "customData", "dataKey": "accountId", "dataKeyType": "String", "comparator": "startWithIgnoreCase", "values": ["acct_"]},
+		{"type": "user", "subType": "customData", "dataKey": "plan", "dataKeyType": "String", "comparator": "!regex", "values": ["^free"]},
+		{"type": "user", "subType": "appVersion", "comparator": "endWith", "values": ["-beta"]}
+	]`), &filters)
+	require.NoError(t, err)
+	operator := AudienceOperator{Operator: OperatorAnd, Filters: filters}
+
+	user := brooks
+	user.AppVersion = "2.1.0-beta"
+	user.CustomData = map[string]interface{}{"accountId": "ACCT_42", "plan": "enterprise"}
+	require.True(t, operator.Evaluate(nil, user, nil))
+
+	require.True(t, operator.Evaluate(nil, user, map[string]interface{}{"plan": "free-trial"}))
+	user.CustomData = map[string]interface{}{"accountId": "ACCT_42", "plan": "free-trial"}
+	require.False(t, operator.Evaluate(nil, user, nil))
+
+	user.CustomData = map[string]interface{}{"accountId": "ACCT_42", "plan": "enterprise"}
+	user.AppVersion = "2.1.0"
+	require.False(t, operator.Evaluate(nil, user, nil))
+}
+
 func Test_CheckBooleanFilter(t *testing.T) {
 	tests := []struct {
 		name       string
@@ -0,0 +1,24 @@
+package bucketing
+
+// Layer makes the experiment features in it mutually exclusive: each user is assigned to at most one of them.
+type Layer struct {
+	Id  string `json:"_id" validate:"required"`
+	Key string `json:"key"`
+	// Experiments split the users of the layer. Users hashed past the sum of their percentages are in no experiment.
+	Experiments []LayerExperiment `json:"experiments"`
+}
+
+type LayerExperiment struct {
+	Feature    string  `json:"_feature"`
+	Percentage float64 `json:"percentage"`
+}
+
+// Holdout serves the control variation of experiment features to a percentage of users, so the combined effect
+// of the experiments can be measured against them.
+type Holdout struct {
+	Id         string  `json:"_id" validate:"required"`
+	Key        string  `json:"key"`
+	Percentage float64 `json:"percentage"`
+	// Features limits the holdout to these experiment features. By default it applies to every experiment feature.
+	Features []string `json:"features"`
+}
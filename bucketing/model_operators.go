@@ -1,10 +1,31 @@
 package bucketing
 
-import "github.com/BIwashi/go-server-sdk/v2/api"
+import (
+	"encoding/json"
+
+	"github.com/BIwashi/go-server-sdk/v2/api"
+)
 
 type AudienceOperator struct {
 	Operator string       `json:"operator"`
 	Filters  MixedFilters `json:"filters"`
+
+	// predicate is compiled by Initialize and run by Evaluate
+	predicate filterPredicate
+}
+
+func (o *AudienceOperator) UnmarshalJSON(data []byte) error {
+	type audienceOperator AudienceOperator
+	if err := json.Unmarshal(data, (*audienceOperator)(o)); err != nil {
+		return err
+	}
+	return o.Initialize()
+}
+
+// Initialize compiles the operator, reusing the predicates its filters compiled when they were parsed.
+func (o *AudienceOperator) Initialize() error {
+	o.predicate = standaloneCompiler.compileOperator(o)
+	return nil
 }
 
 func (o AudienceOperator) GetOperator() string {
@@ -16,23 +37,11 @@ func (o AudienceOperator) GetFilters() []FilterOrOperator {
 }
 
 func (operator AudienceOperator) Evaluate(audiences map[string]NoIdAudience, user api.PopulatedUser, clientCustomData map[string]interface{}) bool {
-	if len(operator.GetFilters()) == 0 {
-		return false
-	}
-	if operator.GetOperator() == OperatorOr {
-		for _, filter := range operator.GetFilters() {
-			if filter.Evaluate(audiences, user, clientCustomData) {
-				return true
-			}
-		}
-		return false
-	} else if operator.GetOperator() == OperatorAnd {
-		for _, filter := range operator.GetFilters() {
-			if !filter.Evaluate(audiences, user, clientCustomData) {
-				return false
-			}
-		}
-		return true
+	predicate := operator.predicate
+	if predicate == nil {
+		// Operators built in code, rather than parsed, are compiled when they are evaluated
+		uninitialized := operator
+		predicate = standaloneCompiler.compileOperator(&uninitialized)
 	}
-	return false
+	return evaluateFilter(predicate, audiences, user, clientCustomData)
 }
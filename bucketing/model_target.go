@@ -9,6 +9,9 @@ type Target struct {
 	Audience     *Audience            `json:"_audience"`
 	Rollout      *Rollout             `json:"rollout"`
 	Distribution []TargetDistribution `json:"distribution"`
+	// BucketingKey names the user field or custom data key that buckets users into the rollout and distribution,
+	// instead of the user_id
+	BucketingKey string `json:"bucketingKey,omitempty"`
 }
 
 func (t *Target) DecideTargetVariation(boundedHash float64) (string, error) {
@@ -17,6 +17,9 @@ func TestAudience_Parsing(t *testing.T) {
 	err = json.Unmarshal(jsonAudience, &audience)
 	require.NoError(t, err)
 
+	// Parsing compiles the filters, whose predicates are functions and never deeply equal
+	requirePredicatesCompiled(t, audience.Filters)
+
 	require.Equal(t, Audience{
 		NoIdAudience: NoIdAudience{
 			Filters: &AudienceOperator{
@@ -98,3 +101,26 @@ func TestAudience_Parsing(t *testing.T) {
 	require.Equal(t, "audienceMatch", audienceFilter.GetType())
 	require.Equal(t, "!=", audienceFilter.GetComparator())
 }
+
+// requirePredicatesCompiled checks that the operator and its filters were compiled when they were parsed, then
+// clears the predicates so the parsed filters can be compared.
+func requirePredicatesCompiled(t *testing.T, operator *AudienceOperator) {
+	require.NotNil(t, operator.predicate)
+	operator.predicate = nil
+	for _, f := range operator.Filters {
+		switch f := f.(type) {
+		case *AudienceOperator:
+			requirePredicatesCompiled(t, f)
+		case *UserFilter:
+			require.NotNil(t, f.predicate)
+			f.predicate = nil
+		case *CustomDataFilter:
+			require.NotNil(t, f.predicate)
+			require.NotNil(t, f.UserFilter.predicate)
+			f.predicate, f.UserFilter.predicate = nil, nil
+		case *AudienceMatchFilter:
+			require.NotNil(t, f.predicate)
+			f.predicate = nil
+		}
+	}
+}
@@ -0,0 +1,8 @@
+//go:build race
+
+package bucketing
+
+func init() {
+	// The race detector allocates, and randomly drops pooled values
+	raceEnabled = true
+}
@@ -0,0 +1,49 @@
+package bucketing
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/BIwashi/go-server-sdk/v2/api"
+)
+
+var ErrInvalidRolloutPreview = errors.New("invalid rollout preview range")
+
+// maxRolloutPreviewPoints bounds the points of a rollout preview, so a small step over a long range can't
+// exhaust memory.
+const maxRolloutPreviewPoints = 10000
+
+// RolloutPreview returns the percentage of users each target of the feature lets through, every step from
+// from until to, both included. Targets without a rollout let every user through.
+func (e *Engine) RolloutPreview(featureKey string, from, to time.Time, step time.Duration) ([]api.TargetRolloutPreview, error) {
+	config, err := e.getConfig()
+	if err != nil {
+		return nil, err
+	}
+	feature := config.GetFeatureForKey(featureKey)
+	if feature == nil {
+		return nil, fmt.Errorf("%w: %s", ErrMissingFeatureForKey, featureKey)
+	}
+	if step <= 0 || to.Before(from) {
+		return nil, fmt.Errorf("%w: step must be positive and from must not be after to", ErrInvalidRolloutPreview)
+	}
+	points := to.Sub(from)/step + 1
+	if points > maxRolloutPreviewPoints {
+		return nil, fmt.Errorf("%w: more than %d points", ErrInvalidRolloutPreview, maxRolloutPreviewPoints)
+	}
+
+	previews := make([]api.TargetRolloutPreview, 0, len(feature.Configuration.Targets))
+	for _, target := range feature.Configuration.Targets {
+		preview := api.TargetRolloutPreview{TargetId: target.Id, Points: make([]api.RolloutPoint, 0, points)}
+		for at := from; !at.After(to); at = at.Add(step) {
+			percentage := 1.0
+			if target.Rollout != nil {
+				percentage = getCurrentRolloutPercentage(*target.Rollout, at)
+			}
+			preview.Points = append(preview.Points, api.RolloutPoint{Time: at, Percentage: percentage})
+		}
+		previews = append(previews, preview)
+	}
+	return previews, nil
+}
@@ -0,0 +1,106 @@
+package bucketing
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/BIwashi/go-server-sdk/v2/api"
+)
+
+// rolloutConfig serves "rollout-var" through a target rolling out linearly from 0% to 100% during March 2024,
+// and a target without a rollout for users with the "everyone" email.
+const rolloutConfig = `{
+	"project": {"_id": "project", "key": "project", "a0_organization": "org"},
+	"environment": {"_id": "environment", "key": "development"},
+	"features": [{
+		"_id": "rollout-feature", "key": "rollout", "type": "release",
+		"variations": [{"_id": "rollout-on", "key": "on", "name": "On", "variables": [{"_var": "rollout-var-id", "value": true}]}],
+		"configuration": {"_id": "rollout-configuration", "targets": [
+			{
+				"_id": "everyone-target",
+				"_audience": {"_id": "everyone-audience", "filters": {"operator": "and", "filters": [{
+					"type": "user", "subType": "email", "comparator": "=", "values": ["everyone"]
+				}]}},
+				"distribution": [{"_variation": "rollout-on", "percentage": 1}]
+			},
+			{
+				"_id": "rollout-target",
+				"_audience": {"_id": "rollout-audience", "filters": {"operator": "and", "filters": [{"type": "all"}]}},
+				"rollout": {
+					"type": "gradual", "startPercentage": 0, "startDate": "2024-03-01T00:00:00Z",
+					"stages": [{"type": "linear", "date": "2024-03-31T00:00:00Z", "percentage": 1}]
+				},
+				"distribution": [{"_variation": "rollout-on", "percentage": 1}]
+			}
+		]}
+	}],
+	"variables": [{"_id": "rollout-var-id", "key": "rollout-var", "type": "Boolean"}]
+}`
+
+type fixedClock time.Time
+
+func (c fixedClock) Now() time.Time {
+	return time.Time(c)
+}
+
+func TestEngine_Clock(t *testing.T) {
+	engine := newTestEngine(t, "engine-test-clock")
+	require.NoError(t, engine.SetConfig([]byte(rolloutConfig), ""))
+	user := api.User{UserId: "user"}.GetPopulatedUser(&api.PlatformData{})
+
+	engine.SetClock(fixedClock(time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)))
+	_, err := engine.EvaluateVariable(user, "rollout-var", VariableTypesBool)
+	require.ErrorIs(t, err, ErrUserRollout)
+
+	engine.SetClock(fixedClock(time.Date(2024, 4, 1, 0, 0, 0, 0, time.UTC)))
+	evaluation, err := engine.EvaluateVariable(user, "rollout-var", VariableTypesBool)
+	require.NoError(t, err)
+	require.Equal(t, true, evaluation.Value)
+
+	// Users pass the rollout as soon as its percentage reaches their hash
+	hash := generateBoundedHashes("user", "rollout-target").RolloutHash
+	passes := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC).Add(time.Duration(hash*30*24) * time.Hour).Add(time.Hour)
+	engine.SetClock(fixedClock(passes))
+	_, err = engine.EvaluateVariable(user, "rollout-var", VariableTypesBool)
+	require.NoError(t, err)
+	engine.SetClock(fixedClock(passes.Add(-2 * time.Hour)))
+	_, err = engine.EvaluateVariable(user, "rollout-var", VariableTypesBool)
+	require.ErrorIs(t, err, ErrUserRollout)
+}
+
+func TestEngine_RolloutPreview(t *testing.T) {
+	engine := newTestEngine(t, "engine-test-rollout-preview")
+	_, err := engine.RolloutPreview("rollout", time.Now(), time.Now(), time.Hour)
+	require.ErrorIs(t, err, ErrConfigNotInitialized)
+	require.NoError(t, engine.SetConfig([]byte(rolloutConfig), ""))
+
+	from := time.Date(2024, 2, 26, 0, 0, 0, 0, time.UTC)
+	previews, err := engine.RolloutPreview("rollout", from, from.Add(40*24*time.Hour), 10*24*time.Hour)
+	require.NoError(t, err)
+	require.Len(t, previews, 2)
+
+	require.Equal(t, "everyone-target", previews[0].TargetId)
+	require.Len(t, previews[0].Points, 5)
+	for _, point := range previews[0].Points {
+		require.Equal(t, 1.0, point.Percentage)
+	}
+
+	require.Equal(t, "rollout-target", previews[1].TargetId)
+	expected := []float64{0, 6.0 / 30, 16.0 / 30, 26.0 / 30, 1}
+	require.Len(t, previews[1].Points, len(expected))
+	for i, point := range previews[1].Points {
+		require.Equal(t, from.Add(time.Duration(i)*10*24*time.Hour), point.Time)
+		require.InDelta(t, expected[i], point.Percentage, 1e-9, point.Time)
+	}
+
+	_, err = engine.RolloutPreview("missing", from, from, time.Hour)
+	require.ErrorIs(t, err, ErrMissingFeatureForKey)
+	_, err = engine.RolloutPreview("rollout", from, from.Add(-time.Hour), time.Hour)
+	require.ErrorIs(t, err, ErrInvalidRolloutPreview)
+	_, err = engine.RolloutPreview("rollout", from, from, 0)
+	require.ErrorIs(t, err, ErrInvalidRolloutPreview)
+	_, err = engine.RolloutPreview("rollout", from, from.Add(365*24*time.Hour), time.Minute)
+	require.ErrorIs(t, err, ErrInvalidRolloutPreview)
+}
@@ -1,81 +1,24 @@
 package bucketing
 
 import (
-	"fmt"
 	"math"
-	"regexp"
 	"strings"
 
 	"github.com/BIwashi/go-server-sdk/v2/api"
 )
 
 func filterForAudienceMatch(filter *AudienceMatchFilter, configAudiences map[string]NoIdAudience, user api.PopulatedUser, clientCustomData map[string]interface{}) bool {
-	audiences := filter.Audiences
-	comparator := filter.GetComparator()
-
-	for _, audience := range audiences {
-		a, ok := configAudiences[audience]
-		if !ok {
-			return false
-		}
-		if a.Filters.Evaluate(configAudiences, user, clientCustomData) {
-			return comparator == "="
-		}
-	}
-	return comparator == "!="
+	return evaluateFilter(standaloneCompiler.compileFilter(filter), configAudiences, user, clientCustomData)
 }
 
 func filterFunctionsBySubtype(filter *UserFilter, user api.PopulatedUser, clientCustomData map[string]interface{}) bool {
-	switch filter.SubType {
-	case SubTypeCountry:
-		return checkStringsFilter(user.Country, filter)
-	case SubTypeEmail:
-		return checkStringsFilter(user.Email, filter)
-	case SubTypeUserID:
-		return checkStringsFilter(user.UserId, filter)
-	case SubTypeAppVersion:
-		return checkVersionFilters(user.AppVersion, filter)
-	case SubTypePlatformVersion:
-		return checkVersionFilters(user.PlatformVersion, filter)
-	case SubTypeDeviceModel:
-		return checkStringsFilter(user.User.DeviceModel, filter)
-	case SubTypePlatform:
-		return checkStringsFilter(user.Platform, filter)
-	default:
-		return false
-	}
+	return evaluateFilter(standaloneCompiler.compileFilter(filter), nil, user, clientCustomData)
 }
 
 func checkCustomData(filter *CustomDataFilter, data map[string]interface{}, clientCustomData map[string]interface{}) bool {
-	operator := filter.GetComparator()
-	var dataValue interface{}
-
-	if _, ok := data[filter.DataKey]; !ok {
-		if v2, ok2 := clientCustomData[filter.DataKey]; ok2 {
-			dataValue = v2
-		}
-	} else {
-		dataValue = data[filter.DataKey]
-	}
-
-	if operator == "exist" {
-		return checkValueExists(dataValue)
-	} else if operator == "!exist" {
-		return !checkValueExists(dataValue)
-	} else if v, ok := dataValue.(string); ok && filter.DataKeyType == "String" {
-		if dataValue == nil {
-			return checkStringsFilter("", filter.UserFilter)
-		} else {
-			return checkStringsFilter(v, filter.UserFilter)
-		}
-	} else if _, ok := dataValue.(float64); ok && filter.DataKeyType == "Number" {
-		return checkNumbersFilterJSONValue(dataValue, filter.UserFilter)
-	} else if v, ok := dataValue.(bool); ok && filter.DataKeyType == "Boolean" {
-		return _checkBooleanFilter(v, filter.UserFilter)
-	} else if dataValue == nil && operator == "!=" {
-		return true
-	}
-	return false
+	ctx := filterContext{clientCustomData: clientCustomData}
+	ctx.user.CustomData = data
+	return standaloneCompiler.compileFilter(filter)(&ctx)
 }
 
 func checkNumbersFilterJSONValue(jsonValue interface{}, filter *UserFilter) bool {
@@ -89,32 +32,7 @@ func _checkNumbersFilter(number float64, filter *UserFilter) bool {
 }
 
 func checkStringsFilter(str string, filter *UserFilter) bool {
-	operator := filter.GetComparator()
-	values := filter.CompiledStringVals
-	if operator == "=" {
-		return str != "" && stringArrayIn(values, str)
-	} else if operator == "!=" {
-		return str != "" && !stringArrayIn(values, str)
-	} else if operator == "exist" {
-		return str != ""
-	} else if operator == "!exist" {
-		return str == ""
-	} else if operator == "contain" {
-		return str != "" && stringArrayContains(values, str)
-	} else if operator == "!contain" {
-		return str == "" || !stringArrayContains(values, str)
-	} else {
-		return false
-	}
-}
-
-func stringArrayIn(arr []string, search string) bool {
-	for _, s := range arr {
-		if s == search {
-			return true
-		}
-	}
-	return false
+	return compileStringMatcher(filter)(str)
 }
 
 func stringArrayContains(substrings []string, search string) bool {
@@ -127,28 +45,7 @@ func stringArrayContains(substrings []string, search string) bool {
 }
 
 func _checkBooleanFilter(b bool, filter *UserFilter) bool {
-	contains := func(arr []bool, search bool) bool {
-		for _, s := range arr {
-			if s == search {
-				return true
-			}
-		}
-		return false
-	}
-	operator := filter.GetComparator()
-	values := filter.CompiledBoolVals
-
-	if operator == "contain" || operator == "=" {
-		return contains(values, b)
-	} else if operator == "!contain" || operator == "!=" {
-		return !contains(values, b)
-	} else if operator == "exist" {
-		return true
-	} else if operator == "!exist" {
-		return false
-	} else {
-		return false
-	}
+	return compileBooleanMatcher(filter.CompiledBoolVals, filter.GetComparator())(b)
 }
 
 func checkVersionFilters(appVersion string, filter *UserFilter) bool {
@@ -175,129 +72,22 @@ func convertToSemanticVersion(version string) string {
 }
 
 func checkVersionValue(filterVersion string, version string, operator string) bool {
-	if version != "" && len(filterVersion) > 0 {
-		options := OptionsType{
-			Lexicographical: false,
-			ZeroExtend:      true,
-		}
-		result := versionCompare(version, filterVersion, options)
-
-		if math.IsNaN(result) {
-			return false
-		} else if result == 0 && strings.Contains(operator, "=") {
-			return true
-		} else if result == 1 && strings.Contains(operator, ">") {
-			return true
-		} else if result == -1 && strings.Contains(operator, "<") {
-			return true
-		}
-	}
-
-	return false
-}
-
-func checkVersionFilter(version string, filterVersions []string, operator string) bool {
-	if version == "" {
+	if version == "" || len(filterVersion) == 0 {
 		return false
 	}
-
-	var parsedVersion = version
-	var parsedOperator = operator
-
-	var not = false
-	if parsedOperator == "!=" {
-		parsedOperator = "="
-		not = true
-	}
-
-	var parsedFilterVersions = filterVersions
-	if parsedOperator != "=" {
-		// remove any non-number and . characters, and remove everything after a hyphen
-		// eg. 1.2.3a-b6 becomes 1.2.3
-		regex1, err := regexp.Compile(`[^(\d|.|\-)]`)
-		if err != nil {
-			fmt.Println("Error compiling regex: ", err)
-		}
-		regex2, err := regexp.Compile(`-.*`)
-		if err != nil {
-			fmt.Println("Error compiling regex: ", err)
-		}
-
-		parsedVersion = regex2.ReplaceAllString(regex1.ReplaceAllString(parsedVersion, ""), "")
-
-		var mappedFilterVersions []string
-		for _, filterVersion := range parsedFilterVersions {
-			mappedFilterVersions = append(mappedFilterVersions, regex2.ReplaceAllString(regex1.ReplaceAllString(filterVersion, ""), ""))
-		}
-		parsedFilterVersions = mappedFilterVersions
-	}
-
-	parsedVersion = convertToSemanticVersion(parsedVersion)
-
-	passed := false
-	// Replace Array.some(), because you can"t access captured data in a closure
-	for _, filterVersion := range parsedFilterVersions {
-		if checkVersionValue(filterVersion, parsedVersion, operator) {
-			passed = true
-			break
-		}
+	options := OptionsType{
+		Lexicographical: false,
+		ZeroExtend:      true,
 	}
+	return versionComparisonPasses(versionCompare(version, filterVersion, options), operator)
+}
 
-	if !not {
-		return passed
-	}
-	return !passed
+func checkVersionFilter(version string, filterVersions []string, operator string) bool {
+	return compileVersionMatcher(filterVersions, operator)(version)
 }
 
 func _checkNumberFilter(num float64, filterNums []float64, operator string) bool {
-	if operator != "" {
-		if operator == "exist" {
-			return !math.IsNaN(num)
-		} else if operator == "!exist" {
-			return math.IsNaN(num)
-		}
-	}
-
-	if math.IsNaN(num) {
-		return false
-	}
-
-	if operator == "!=" {
-		passesFilter := true
-		for _, filterNum := range filterNums {
-			if math.IsNaN(filterNum) || num == filterNum {
-				passesFilter = false
-			}
-		}
-		return passesFilter
-	}
-
-	// replace filterNums.some() logic
-	someValue := false
-	for _, filterNum := range filterNums {
-		if math.IsNaN(filterNum) {
-			continue
-		}
-
-		if operator == "=" {
-			someValue = num == filterNum
-		} else if operator == ">" {
-			someValue = num > filterNum
-		} else if operator == ">=" {
-			someValue = num >= filterNum
-		} else if operator == "<" {
-			someValue = num < filterNum
-		} else if operator == "<=" {
-			someValue = num <= filterNum
-		} else {
-			continue
-		}
-
-		if someValue {
-			return true
-		}
-	}
-	return someValue
+	return compileNumberMatcher(filterNums, operator)(num)
 }
 
 /**
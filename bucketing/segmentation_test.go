@@ -1,7 +1,9 @@
 package bucketing
 
 import (
+	"encoding/json"
 	"math"
+	"regexp"
 	"testing"
 
 	"github.com/stretchr/testify/require"
@@ -652,6 +654,7 @@ func TestEvaluateOperator_NestedAnd(t *testing.T) {
 
 	// If the second AND filter fails, should fail to match
 	topLevelFilter.filter.Comparator = "="
+	require.NoError(t, topLevelFilter.Initialize())
 	result = _evaluateOperator(AudienceOperator{Operator: "and", Filters: MixedFilters{nestedOperator, topLevelFilter}}, nil, brooks, nil)
 	require.False(t, result)
 }
@@ -1816,3 +1819,404 @@ func TestDoesUserPassFilter_WithUserPlatformFilter(t *testing.T) {
 		}
 	}
 }
+
+func TestDoesUserPassFilter_WithUserNameAndLanguageFilters(t *testing.T) {
+	user := api.PopulatedUser{
+		User: api.User{
+			UserId:   "1234",
+			Name:     "Jane Doe",
+			Language: "fr-CA",
+		},
+		PlatformData: (&api.PlatformData{}).Default(),
+	}
+
+	testCases := []struct {
+		name       string
+		subType    string
+		comparator string
+		values     []interface{}
+		expected   bool
+	}{
+		{
+			name:       "User name equals filter",
+			subType:    SubTypeName,
+			comparator: ComparatorEqual,
+			values:     []interface{}{"Jane Doe"},
+			expected:   true,
+		},
+		{
+			name:       "User name does not contain filter",
+			subType:    SubTypeName,
+			comparator: ComparatorNotContain,
+			values:     []interface{}{"Doe"},
+			expected:   false,
+		},
+		{
+			name:       "User language in filter set",
+			subType:    SubTypeLanguage,
+			comparator: ComparatorEqual,
+			values:     []interface{}{"en", "fr-CA"},
+			expected:   true,
+		},
+		{
+			name:       "User language starts with filter",
+			subType:    SubTypeLanguage,
+			comparator: ComparatorStartWith + ComparatorIgnoreCaseSuffix,
+			values:     []interface{}{"FR"},
+			expected:   true,
+		},
+		{
+			name:       "User language exists",
+			subType:    SubTypeLanguage,
+			comparator: ComparatorExist,
+			values:     []interface{}{},
+			expected:   true,
+		},
+	}
+
+	for _, tc := range testCases {
+		testFilter := &UserFilter{
+			filter: filter{
+				Type:       "user",
+				SubType:    tc.subType,
+				Comparator: tc.comparator,
+			},
+			Values: tc.values,
+		}
+		require.NoError(t, testFilter.Initialize())
+		result := testFilter.Evaluate(nil, user, nil)
+		if result != tc.expected {
+			t.Errorf("doesUserPassFilter(%v) = %v; want %v", tc.name, result, tc.expected)
+		}
+	}
+}
+
+func TestDoesUserPassFilter_WithUserAppBuildFilter(t *testing.T) {
+	testCases := []struct {
+		name       string
+		appBuild   string
+		comparator string
+		values     []interface{}
+		expected   bool
+	}{
+		{
+			name:       "App build greater than number",
+			appBuild:   "1024",
+			comparator: ComparatorGreater,
+			values:     []interface{}{1000.0},
+			expected:   true,
+		},
+		{
+			name:       "App build compared numerically, not lexicographically",
+			appBuild:   "99",
+			comparator: ComparatorLess,
+			values:     []interface{}{"100"},
+			expected:   true,
+		},
+		{
+			name:       "App build equals numeric string",
+			appBuild:   "42.0",
+			comparator: ComparatorEqual,
+			values:     []interface{}{"42"},
+			expected:   true,
+		},
+		{
+			name:       "App build not equal to numbers",
+			appBuild:   "42",
+			comparator: ComparatorNotEqual,
+			values:     []interface{}{41.0, 43.0},
+			expected:   true,
+		},
+		{
+			name:       "Non-numeric app build fails numeric comparison",
+			appBuild:   "nightly",
+			comparator: ComparatorGreaterEqual,
+			values:     []interface{}{1.0},
+			expected:   false,
+		},
+		{
+			name:       "Non-numeric app build compared as a string",
+			appBuild:   "2024.05-rc1",
+			comparator: ComparatorEqual,
+			values:     []interface{}{"2024.05-rc1"},
+			expected:   true,
+		},
+		{
+			name:       "Numeric app build against string values",
+			appBuild:   "42",
+			comparator: ComparatorEqual,
+			values:     []interface{}{"rc1", "42"},
+			expected:   true,
+		},
+		{
+			name:       "App build starts with filter",
+			appBuild:   "1024",
+			comparator: ComparatorStartWith,
+			values:     []interface{}{"10"},
+			expected:   true,
+		},
+		{
+			name:       "Missing app build",
+			appBuild:   "",
+			comparator: ComparatorLessEqual,
+			values:     []interface{}{10.0},
+			expected:   false,
+		},
+		{
+			name:       "Missing app build does not exist",
+			appBuild:   "",
+			comparator: ComparatorNotExist,
+			values:     []interface{}{},
+			expected:   true,
+		},
+	}
+
+	for _, tc := range testCases {
+		user := api.PopulatedUser{
+			User: api.User{
+				UserId:   "1234",
+				AppBuild: tc.appBuild,
+			},
+			PlatformData: (&api.PlatformData{}).Default(),
+		}
+		testFilter := &UserFilter{
+			filter: filter{
+				Type:       "user",
+				SubType:    SubTypeAppBuild,
+				Comparator: tc.comparator,
+			},
+			Values: tc.values,
+		}
+		require.NoError(t, testFilter.Initialize())
+		result := testFilter.Evaluate(nil, user, nil)
+		if result != tc.expected {
+			t.Errorf("doesUserPassFilter(%v) = %v; want %v", tc.name, result, tc.expected)
+		}
+	}
+}
+
+func TestDoesUserPassFilter_WithUserIPFilter(t *testing.T) {
+	testCases := []struct {
+		name       string
+		ip         string
+		comparator string
+		values     []interface{}
+		expected   bool
+	}{
+		{
+			name:       "User IP equals filter",
+			ip:         "203.0.113.7",
+			comparator: ComparatorEqual,
+			values:     []interface{}{"198.51.100.1", "203.0.113.7"},
+			expected:   true,
+		},
+		{
+			name:       "User IP does not equal a range",
+			ip:         "203.0.113.7",
+			comparator: ComparatorEqual,
+			values:     []interface{}{"203.0.113.0/24"},
+			expected:   false,
+		},
+		{
+			name:       "User IP not equal to filter",
+			ip:         "203.0.113.7",
+			comparator: ComparatorNotEqual,
+			values:     []interface{}{"203.0.113.8"},
+			expected:   true,
+		},
+		{
+			name:       "User IP in IPv4 range",
+			ip:         "10.20.30.40",
+			comparator: ComparatorContain,
+			values:     []interface{}{"192.168.0.0/16", "10.0.0.0/8"},
+			expected:   true,
+		},
+		{
+			name:       "User IP outside IPv4 range",
+			ip:         "11.0.0.1",
+			comparator: ComparatorContain,
+			values:     []interface{}{"10.0.0.0/8"},
+			expected:   false,
+		},
+		{
+			name:       "User IP in unmasked range",
+			ip:         "10.1.2.3",
+			comparator: ComparatorContain,
+			values:     []interface{}{"10.1.2.200/24"},
+			expected:   true,
+		},
+		{
+			name:       "User IP in IPv6 range",
+			ip:         "2001:db8:abcd::1",
+			comparator: ComparatorContain,
+			values:     []interface{}{"2001:db8::/32"},
+			expected:   true,
+		},
+		{
+			name:       "IPv4-mapped user IP in IPv4 range",
+			ip:         "::ffff:10.0.0.1",
+			comparator: ComparatorContain,
+			values:     []interface{}{"10.0.0.0/8"},
+			expected:   true,
+		},
+		{
+			name:       "User IP not in range",
+			ip:         "8.8.8.8",
+			comparator: ComparatorNotContain,
+			values:     []interface{}{"10.0.0.0/8"},
+			expected:   true,
+		},
+		{
+			name:       "Missing user IP not in range",
+			ip:         "",
+			comparator: ComparatorNotContain,
+			values:     []interface{}{"10.0.0.0/8"},
+			expected:   true,
+		},
+		{
+			name:       "Missing user IP not equal",
+			ip:         "",
+			comparator: ComparatorNotEqual,
+			values:     []interface{}{"10.0.0.1"},
+			expected:   false,
+		},
+		{
+			name:       "User IP exists",
+			ip:         "2001:db8::1",
+			comparator: ComparatorExist,
+			values:     []interface{}{},
+			expected:   true,
+		},
+		{
+			name:       "Unparseable user IP does not exist",
+			ip:         "not-an-ip",
+			comparator: ComparatorNotExist,
+			values:     []interface{}{},
+			expected:   true,
+		},
+		{
+			name:       "Invalid filter range never passes",
+			ip:         "10.0.0.1",
+			comparator: ComparatorNotContain,
+			values:     []interface{}{"10.0.0.0/33"},
+			expected:   false,
+		},
+		{
+			name:       "Unsupported comparator never passes",
+			ip:         "10.0.0.1",
+			comparator: ComparatorStartWith,
+			values:     []interface{}{"10."},
+			expected:   false,
+		},
+	}
+
+	for _, tc := range testCases {
+		user := api.PopulatedUser{
+			User: api.User{
+				UserId: "1234",
+				IP:     tc.ip,
+			},
+			PlatformData: (&api.PlatformData{}).Default(),
+		}
+		testFilter := &UserFilter{
+			filter: filter{
+				Type:       "user",
+				SubType:    SubTypeIP,
+				Comparator: tc.comparator,
+			},
+			Values: tc.values,
+		}
+		require.NoError(t, testFilter.Initialize())
+		result := testFilter.Evaluate(nil, user, nil)
+		if result != tc.expected {
+			t.Errorf("doesUserPassFilter(%v) = %v; want %v", tc.name, result, tc.expected)
+		}
+	}
+}
+
+func Test_CompareVersionsMatchesVersionCompare(t *testing.T) {
+	versions := []string{"", "1", "1.2", "1.2.3", "1..3", "1.2.", "10.3.1", "2.0.0.1", "01.2", "1.2.3a", "a", "1.-2", "99999999999999999999999"}
+	options := OptionsType{ZeroExtend: true}
+	for _, version := range versions {
+		for _, filterVersion := range versions {
+			if filterVersion == "" {
+				continue
+			}
+			expected := versionCompare(convertToSemanticVersion(version), filterVersion, options)
+			got := compareVersions(version, filterVersion)
+			if math.IsNaN(expected) {
+				require.True(t, math.IsNaN(got), "compareVersions(%q, %q) = %v; want NaN", version, filterVersion, got)
+			} else {
+				require.Equal(t, expected, got, "compareVersions(%q, %q)", version, filterVersion)
+			}
+		}
+	}
+}
+
+func TestSegmentation_CompiledFilters(t *testing.T) {
+	audiences := map[string]NoIdAudience{
+		"canada": {Filters: &AudienceOperator{Operator: OperatorAnd, Filters: MixedFilters{
+			&UserFilter{filter: filter{Type: TypeUser, SubType: SubTypeCountry, Comparator: ComparatorEqual}, CompiledStringVals: []string{"Canada", "Mexico"}},
+		}}},
+		"fans": {Filters: &AudienceOperator{Operator: OperatorAnd, Filters: MixedFilters{
+			&AudienceMatchFilter{filter: filter{Type: TypeAudienceMatch, Comparator: ComparatorEqual}, Audiences: []string{"canada"}},
+			&CustomDataFilter{
+				UserFilter:  &UserFilter{filter: filter{Type: TypeUser, SubType: SubTypeCustomData, Comparator: ComparatorEqual}, CompiledStringVals: []string{"hockey"}},
+				DataKey:     "sport",
+				DataKeyType: DataKeyTypeString,
+			},
+		}}},
+	}
+	compiler := newFilterCompiler(audiences)
+	compiler.compileAudiences()
+	fans := compiler.audience("fans")
+
+	user := brooks
+	user.CustomData = map[string]interface{}{"sport": "curling"}
+	require.False(t, evaluateFilter(fans, nil, user, map[string]interface{}{"sport": "hockey"}))
+
+	// Private custom data takes precedence, and client custom data fills in missing keys
+	user.PrivateCustomData = map[string]interface{}{"sport": "hockey"}
+	require.True(t, evaluateFilter(fans, nil, user, nil))
+	user.CustomData, user.PrivateCustomData = nil, nil
+	require.True(t, evaluateFilter(fans, nil, user, map[string]interface{}{"sport": "hockey"}))
+
+	user.Country = "France"
+	require.False(t, evaluateFilter(fans, nil, user, map[string]interface{}{"sport": "hockey"}))
+	require.Nil(t, compiler.audience("missing"))
+}
+
+func TestAudienceOperator_EvaluateReusesParsedPredicates(t *testing.T) {
+	var audiences map[string]NoIdAudience
+	require.NoError(t, json.Unmarshal([]byte(`{
+		"canada": {"filters": {"operator": "and", "filters": [
+			{"type": "user", "subType": "country", "comparator": "=", "values": ["Canada"]}
+		]}}
+	}`), &audiences))
+	var operator AudienceOperator
+	require.NoError(t, json.Unmarshal([]byte(`{"operator": "and", "filters": [
+		{"type": "audienceMatch", "comparator": "=", "_audiences": ["canada"]},
+		{"type": "user", "subType": "customData", "comparator": "=", "dataKey": "sport", "dataKeyType": "String", "values": ["hockey"]}
+	]}`), &operator))
+
+	ccd := map[string]interface{}{"sport": "hockey"}
+	require.True(t, operator.Evaluate(audiences, brooks, ccd))
+	require.False(t, operator.Evaluate(audiences, brooks, nil))
+	require.False(t, operator.Evaluate(nil, brooks, ccd))
+
+	if raceEnabled {
+		t.Skip("allocations are not representative with the race detector")
+	}
+	allocs := testing.AllocsPerRun(100, func() {
+		_ = operator.Evaluate(audiences, brooks, ccd)
+	})
+	require.Zero(t, allocs)
+}
+
+func Test_CleanVersion(t *testing.T) {
+	characters := regexp.MustCompile(`[^(\d|.|\-)]`)
+	suffix := regexp.MustCompile(`-.*`)
+	for _, version := range []string{"", "1.2.3", "1.2.3a-b6", "v2.0", "1.2-beta.3", "-1", "1.(2)|3", "10.3.1 build 7"} {
+		require.Equal(t, suffix.ReplaceAllString(characters.ReplaceAllString(version, ""), ""), cleanVersion(version), version)
+	}
+}
@@ -0,0 +1,36 @@
+package bucketing
+
+import (
+	"github.com/BIwashi/go-server-sdk/v2/api"
+	"github.com/BIwashi/go-server-sdk/v2/util"
+)
+
+// withStoredUserData merges the stored custom data of the user into the user when EdgeDB is enabled in the project
+// settings. Custom data sent with the user takes precedence over stored data. When persist is set, the merged
+// custom data is also stored; only evaluations that queue events persist it, so previews and re-evaluations
+// don't write to the store.
+func (inputs evaluationInputs) withStoredUserData(config *configBody, user api.PopulatedUser, persist bool) api.PopulatedUser {
+	if inputs.userData == nil || config == nil || !config.Project.Settings.EdgeDB.Enabled || user.UserId == "" {
+		return user
+	}
+
+	var merged map[string]interface{}
+	if persist {
+		var err error
+		merged, err = inputs.userData.Merge(user.UserId, user.CustomData)
+		if err != nil {
+			util.Warnf("Error storing data of user %s: %s", user.UserId, err)
+		}
+	} else {
+		stored, err := inputs.userData.Get(user.UserId)
+		if err != nil {
+			util.Warnf("Error reading stored data of user %s: %s", user.UserId, err)
+			return user
+		}
+		merged, _ = api.MergeCustomData(stored, user.CustomData)
+	}
+	if len(merged) > 0 {
+		user.CustomData = merged
+	}
+	return user
+}
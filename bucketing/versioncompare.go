@@ -12,14 +12,17 @@ type OptionsType struct {
 	ZeroExtend      bool
 }
 
+var (
+	lexicographicalVersionPartRegex = regexp.MustCompile(`^\d+[A-Za-z]*$`)
+	numericVersionPartRegex         = regexp.MustCompile(`^\d+$`)
+)
+
 func hasValidParts(lexicographical bool, parts []string) bool {
+	regex := numericVersionPartRegex
+	if lexicographical {
+		regex = lexicographicalVersionPartRegex
+	}
 	for _, part := range parts {
-		var regex *regexp.Regexp
-		if lexicographical {
-			regex = regexp.MustCompile(`^\d+[A-Za-z]*$`)
-		} else {
-			regex = regexp.MustCompile(`^\d+$`)
-		}
 		if !regex.MatchString(part) {
 			return false
 		}
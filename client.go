@@ -20,6 +20,7 @@ import (
 	"github.com/BIwashi/go-server-sdk/v2/util"
 
 	"github.com/BIwashi/go-server-sdk/v2/api"
+	"github.com/BIwashi/go-server-sdk/v2/geoip"
 	"github.com/BIwashi/go-server-sdk/v2/proto"
 	"github.com/matryer/try"
 )
@@ -55,6 +56,9 @@ type Client struct {
 	// Set to true when the client has been initialized, regardless of whether the config has loaded successfully.
 	isInitialized                bool
 	internalOnInitializedChannel chan bool
+	overrides                    *overrideStore
+	watchers                     *variableWatchers
+	geoIP                        *geoip.Database
 }
 
 type LocalBucketing interface {
@@ -63,6 +67,12 @@ type LocalBucketing interface {
 	GenerateBucketedConfigForUser(user User) (ret *BucketedUserConfig, err error)
 	SetClientCustomData(map[string]interface{}) error
 	Variable(user User, key string, variableType string) (variable Variable, err error)
+	VariableWithoutEvents(user User, key string, variableType string) (variable Variable, err error)
+	VariableWithCheck(user User, key string, variableType string, check func(value interface{}) error) (variable Variable, err error)
+	Variables(user User, variableTypes map[string]string) (variables map[string]Variable, err error)
+	FeatureIdForKey(featureKey string) (featureId string, err error)
+	RolloutPreview(featureKey string, from, to time.Time, step time.Duration) ([]TargetRolloutPreview, error)
+	Explain(user User, variableKey string) (*Explanation, error)
 	Close()
 }
 
@@ -79,7 +89,7 @@ type service struct {
 
 // NewClient creates a new API client.
 // optionally pass a custom http.Client to allow for advanced features such as caching.
-func NewClient(sdkKey string, options *Options) (*Client, error) {
+func NewClient(sdkKey string, options *Options) (_ *Client, err error) {
 	if sdkKey == "" {
 		err := errors.New("missing sdk key! Call NewClient with a valid sdk key")
 		util.Errorf("%v", err)
@@ -88,9 +98,17 @@ func NewClient(sdkKey string, options *Options) (*Client, error) {
 	if !sdkKeyIsValid(sdkKey) {
 		return nil, fmt.Errorf("Invalid sdk key. Call NewClient with a valid sdk key.")
 	}
+	if options.Offline {
+		if options.EnableCloudBucketing {
+			return nil, errors.New("offline mode is not available in cloud bucketing mode")
+		}
+		if len(options.BootstrapConfig) == 0 && options.BootstrapConfigPath == "" {
+			return nil, ErrOfflineWithoutBootstrapConfig
+		}
+	}
 	options.CheckDefaults()
 	cfg := NewConfiguration(options)
-	c := &Client{sdkKey: sdkKey}
+	c := &Client{sdkKey: sdkKey, overrides: newOverrideStore(), watchers: newVariableWatchers()}
 	c.cfg = cfg
 	c.ctx = context.Background()
 	c.common.client = c
@@ -104,6 +122,19 @@ func NewClient(sdkKey string, options *Options) (*Client, error) {
 	if c.DevCycleOptions.Logger != nil {
 		util.SetLogger(c.DevCycleOptions.Logger)
 	}
+	if options.GeoIPDatabasePath != "" {
+		geoIP, openErr := geoip.Open(options.GeoIPDatabasePath, geoip.DefaultReloadInterval)
+		if openErr != nil {
+			return nil, fmt.Errorf("Error opening GeoIP database: %w", openErr)
+		}
+		c.geoIP = geoIP
+		defer func() {
+			// Stop reloading the database when the client is returned with an error
+			if err != nil {
+				geoIP.Close()
+			}
+		}()
+	}
 	if c.IsLocalBucketing() {
 		util.Infof("Using Native Bucketing")
 
@@ -121,6 +152,30 @@ func NewClient(sdkKey string, options *Options) (*Client, error) {
 		}
 
 		c.configManager = NewEnvironmentConfigManager(sdkKey, c.localBucketing, options, c.cfg)
+		c.configManager.onConfigStored = c.watchers.notify
+
+		bootstrapConfig, err := options.loadBootstrapConfig()
+		if err != nil {
+			return c, fmt.Errorf("Error loading bootstrap config: %w", err)
+		}
+		if bootstrapConfig != nil {
+			err = c.configManager.bootstrap(bootstrapConfig)
+			if err != nil {
+				return c, fmt.Errorf("Error loading bootstrap config: %w", err)
+			}
+		}
+
+		if options.Offline {
+			util.Infof("Offline mode enabled, serving the bootstrap config without polling")
+			c.handleInitialization()
+			return c, nil
+		}
+
+		err = c.configManager.loadCachedConfig()
+		if err != nil {
+			util.Warnf("Ignoring config cache: %s", err)
+		}
+
 		c.configManager.StartPolling(options.ConfigPollingIntervalMS)
 
 		if c.DevCycleOptions.OnInitializedChannel != nil {
@@ -132,6 +187,11 @@ func NewClient(sdkKey string, options *Options) (*Client, error) {
 		} else {
 			err := c.configManager.initialFetch()
 			c.handleInitialization()
+			if err != nil && c.configManager.HasConfig() {
+				// The bootstrap or cached config keeps the client usable until polling succeeds
+				util.Warnf("Initial config fetch failed, serving the bootstrap or cached config: %s", err)
+				return c, nil
+			}
 			return c, err
 		}
 	} else {
@@ -237,13 +297,28 @@ Get all features by key for user data
 @return map[string]Feature
 */
 func (c *Client) AllFeatures(user User) (map[string]Feature, error) {
+	return c.AllFeaturesCtx(c.ctx, user)
+}
+
+/*
+AllFeaturesCtx - Get all features by key for user data, honouring the cancellation and deadline of ctx
+
+  - @param ctx context.Context - for cancellation and deadlines of cloud bucketing requests.
+
+  - @param body
+
+@return map[string]Feature
+*/
+func (c *Client) AllFeaturesCtx(ctx context.Context, user User) (map[string]Feature, error) {
+	user = c.enrichUser(user)
 	if c.IsLocalBucketing() {
 		if c.hasConfig() {
-			user, err := c.generateBucketedConfig(user)
+			config, err := c.generateBucketedConfig(user)
 			if err != nil {
 				return nil, fmt.Errorf("error generating bucketed config: %w", err)
 			}
-			return user.Features, err
+			c.applyOverridesToFeatures(user, config)
+			return config.Features, err
 		} else {
 			util.Warnf("AllFeatures called before client initialized")
 			return map[string]Feature{}, nil
@@ -268,7 +343,7 @@ func (c *Client) AllFeatures(user User) (map[string]Feature, error) {
 	// body params
 	postBody = &populatedUser
 
-	r, rBody, err := c.performRequest(path, httpMethod, postBody, headers, queryParams)
+	r, rBody, err := c.performRequest(ctx, path, httpMethod, postBody, headers, queryParams)
 
 	if err != nil {
 		return nil, err
@@ -295,7 +370,24 @@ VariableValue - Get variable value by key for user data
     -@return interface{}
 */
 func (c *Client) VariableValue(userdata User, key string, defaultValue interface{}) (interface{}, error) {
-	variable, err := c.Variable(userdata, key, defaultValue)
+	return c.VariableValueCtx(c.ctx, userdata, key, defaultValue)
+}
+
+/*
+VariableValueCtx - Get variable value by key for user data, honouring the cancellation and deadline of ctx
+
+  - @param ctx context.Context - for cancellation and deadlines of cloud bucketing requests.
+
+  - @param body
+
+  - @param key Variable key
+
+  - @param defaultValue Default value
+
+    -@return interface{}
+*/
+func (c *Client) VariableValueCtx(ctx context.Context, userdata User, key string, defaultValue interface{}) (interface{}, error) {
+	variable, err := c.VariableCtx(ctx, userdata, key, defaultValue)
 	return variable.Value, err
 }
 
@@ -311,9 +403,34 @@ Variable - Get variable by key for user data
     -@return Variable
 */
 func (c *Client) Variable(userdata User, key string, defaultValue interface{}) (result Variable, err error) {
+	return c.VariableCtx(c.ctx, userdata, key, defaultValue)
+}
+
+/*
+VariableCtx - Get variable by key for user data, honouring the cancellation and deadline of ctx.
+If ctx is done before a cloud bucketing request completes, the default value is returned along with ctx.Err().
+
+  - @param ctx context.Context - for cancellation and deadlines of cloud bucketing requests.
+
+  - @param body
+
+  - @param key Variable key
+
+  - @param defaultValue Default value
+
+    -@return Variable
+*/
+func (c *Client) VariableCtx(ctx context.Context, userdata User, key string, defaultValue interface{}) (result Variable, err error) {
+	return c.variable(ctx, userdata, key, defaultValue, nil)
+}
+
+// variable evaluates the variable like VariableCtx. A served value that check rejects is defaulted with a type
+// mismatch, and counted as defaulted.
+func (c *Client) variable(ctx context.Context, userdata User, key string, defaultValue interface{}, check func(value interface{}) error) (result Variable, err error) {
 	if key == "" {
 		return Variable{}, errors.New("invalid key provided for call to Variable")
 	}
+	userdata = c.enrichUser(userdata)
 
 	convertedDefaultValue := convertDefaultValueType(defaultValue)
 	variableType, err := variableTypeFromValue(key, convertedDefaultValue, c.IsLocalBucketing())
@@ -328,12 +445,18 @@ func (c *Client) Variable(userdata User, key string, defaultValue interface{}) (
 	defer func() {
 		if r := recover(); r != nil {
 			// Return a usable default value in a panic situation
+			variable.Eval = EvalDetails{Reason: EvalReasonError}
 			result = variable
 			err = fmt.Errorf("recovered from panic in Variable eval: %v ", r)
 			util.Errorf("%v", err)
 		}
 	}()
 
+	if c.applyOverride(userdata, &variable) {
+		applyValueCheck(&variable, check)
+		return variable, nil
+	}
+
 	if c.IsLocalBucketing() {
 		if !c.hasConfig() {
 			util.Warnf("Variable called before client initialized, returning default value")
@@ -343,24 +466,16 @@ func (c *Client) Variable(userdata User, key string, defaultValue interface{}) (
 				util.Warnf("Error queuing aggregate event: ", err)
 			}
 
+			variable.Eval = EvalDetails{Reason: EvalReasonNotInitialized}
 			return variable, nil
 		}
-		bucketedVariable, err := c.localBucketing.Variable(userdata, key, variableType)
-
-		sameTypeAsDefault := compareTypes(bucketedVariable.Value, convertedDefaultValue)
-		if bucketedVariable.Value != nil && (sameTypeAsDefault || defaultValue == nil) {
-			variable.Type_ = bucketedVariable.Type_
-			variable.Value = bucketedVariable.Value
-			variable.IsDefaulted = false
+		var bucketedVariable Variable
+		if check != nil {
+			bucketedVariable, err = c.localBucketing.VariableWithCheck(userdata, key, variableType, check)
 		} else {
-			if !sameTypeAsDefault && bucketedVariable.Value != nil {
-				util.Warnf("Type mismatch for variable %s. Expected type %s, got %s",
-					key,
-					reflect.TypeOf(defaultValue).String(),
-					reflect.TypeOf(bucketedVariable.Value).String(),
-				)
-			}
+			bucketedVariable, err = c.localBucketing.Variable(userdata, key, variableType)
 		}
+		applyBucketedVariable(&variable, bucketedVariable, defaultValue)
 		return variable, err
 	}
 
@@ -369,7 +484,7 @@ func (c *Client) Variable(userdata User, key string, defaultValue interface{}) (
 	var (
 		httpMethod          = strings.ToUpper("Post")
 		postBody            interface{}
-		localVarReturnValue Variable
+		localVarReturnValue cloudVariable
 	)
 
 	// create path and map variables
@@ -382,9 +497,10 @@ func (c *Client) Variable(userdata User, key string, defaultValue interface{}) (
 	// userdata params
 	postBody = &populatedUser
 
-	r, body, err := c.performRequest(path, httpMethod, postBody, headers, queryParams)
+	r, body, err := c.performRequest(ctx, path, httpMethod, postBody, headers, queryParams)
 
 	if err != nil {
+		variable.Eval = EvalDetails{Reason: EvalReasonError}
 		return variable, err
 	}
 
@@ -392,21 +508,30 @@ func (c *Client) Variable(userdata User, key string, defaultValue interface{}) (
 		// If we succeed, return the data, otherwise pass on to decode error.
 		err = decode(&localVarReturnValue, body, r.Header.Get("Content-Type"))
 		if err == nil && localVarReturnValue.Value != nil {
+			variable.Eval = localVarReturnValue.evalDetails()
 			if compareTypes(localVarReturnValue.Value, convertedDefaultValue) {
 				variable.Value = localVarReturnValue.Value
 				variable.IsDefaulted = false
+				applyValueCheck(&variable, check)
 			} else {
 				util.Warnf("Type mismatch for variable %s. Expected type %s, got %s",
 					key,
 					reflect.TypeOf(defaultValue).String(),
 					reflect.TypeOf(localVarReturnValue.Value).String(),
 				)
+				variable.Eval.Reason = EvalReasonTypeMismatch
 			}
 
 			return variable, err
 		}
 	}
 
+	if r.StatusCode == http.StatusNotFound {
+		variable.Eval = EvalDetails{Reason: EvalReasonVariableNotFound}
+	} else {
+		variable.Eval = EvalDetails{Reason: EvalReasonError}
+	}
+
 	var v ErrorResponse
 	err = decode(&v, body, r.Header.Get("Content-Type"))
 	if err != nil {
@@ -417,7 +542,134 @@ func (c *Client) Variable(userdata User, key string, defaultValue interface{}) (
 	return variable, nil
 }
 
+// applyBucketedVariable serves the locally bucketed value on a defaulted variable,
+// unless the bucketed value is missing or its type differs from the default value.
+func applyBucketedVariable(variable *Variable, bucketedVariable Variable, defaultValue interface{}) {
+	variable.Eval = bucketedVariable.Eval
+
+	sameTypeAsDefault := compareTypes(bucketedVariable.Value, variable.DefaultValue)
+	if bucketedVariable.Value != nil && (sameTypeAsDefault || defaultValue == nil) {
+		variable.Type_ = bucketedVariable.Type_
+		variable.Value = bucketedVariable.Value
+		variable.IsDefaulted = false
+	} else {
+		if !sameTypeAsDefault && bucketedVariable.Value != nil {
+			util.Warnf("Type mismatch for variable %s. Expected type %s, got %s",
+				variable.Key,
+				reflect.TypeOf(defaultValue).String(),
+				reflect.TypeOf(bucketedVariable.Value).String(),
+			)
+			variable.Eval.Reason = EvalReasonTypeMismatch
+		}
+	}
+}
+
+// applyValueCheck defaults a served variable whose value check rejects, as a type mismatch.
+func applyValueCheck(variable *Variable, check func(value interface{}) error) {
+	if check == nil || variable.IsDefaulted || check(variable.Value) == nil {
+		return
+	}
+	variable.Value = variable.DefaultValue
+	variable.IsDefaulted = true
+	variable.Eval = EvalDetails{Reason: EvalReasonTypeMismatch}
+}
+
+/*
+Variables - Get several variables by key for user data in one pass
+
+  - @param body
+
+  - @param defaultValues Default value for each requested variable key
+
+    -@return map[string]Variable
+*/
+func (c *Client) Variables(userdata User, defaultValues map[string]interface{}) (map[string]Variable, error) {
+	return c.VariablesCtx(c.ctx, userdata, defaultValues)
+}
+
+// VariablesCtx evaluates several variables for the user, honouring the cancellation and deadline of ctx.
+// In local bucketing mode the user is populated once and each feature owning a requested variable is evaluated
+// once. In cloud bucketing mode all the variables of the user are requested at once.
+func (c *Client) VariablesCtx(ctx context.Context, userdata User, defaultValues map[string]interface{}) (result map[string]Variable, err error) {
+	userdata = c.enrichUser(userdata)
+	variables := make(map[string]Variable, len(defaultValues))
+	variableTypes := make(map[string]string, len(defaultValues))
+	for key, defaultValue := range defaultValues {
+		if key == "" {
+			return nil, errors.New("invalid key provided for call to Variables")
+		}
+		convertedDefaultValue := convertDefaultValueType(defaultValue)
+		variableType, err := variableTypeFromValue(key, convertedDefaultValue, c.IsLocalBucketing())
+		if err != nil {
+			return nil, err
+		}
+		variables[key] = Variable{
+			BaseVariable: BaseVariable{Key: key, Value: convertedDefaultValue, Type_: variableType},
+			DefaultValue: convertedDefaultValue,
+			IsDefaulted:  true,
+		}
+		variableTypes[key] = variableType
+	}
+
+	for key, variable := range variables {
+		if c.applyOverride(userdata, &variable) {
+			variables[key] = variable
+			delete(variableTypes, key)
+		}
+	}
+
+	if !c.IsLocalBucketing() {
+		return c.cloudVariables(ctx, userdata, variables, variableTypes)
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			// Return usable default values in a panic situation
+			for key := range variableTypes {
+				variable := variables[key]
+				variable.Eval = EvalDetails{Reason: EvalReasonError}
+				variables[key] = variable
+			}
+			result = variables
+			err = fmt.Errorf("recovered from panic in Variables eval: %v ", r)
+			util.Errorf("%v", err)
+		}
+	}()
+
+	if !c.hasConfig() {
+		util.Warnf("Variables called before client initialized, returning default values")
+		for key := range variableTypes {
+			variable := variables[key]
+			err = c.eventQueue.QueueVariableDefaultedEvent(key)
+			if err != nil {
+				util.Warnf("Error queuing aggregate event: ", err)
+			}
+			variable.Eval = EvalDetails{Reason: EvalReasonNotInitialized}
+			variables[key] = variable
+		}
+		return variables, nil
+	}
+
+	bucketedVariables, err := c.localBucketing.Variables(userdata, variableTypes)
+	if err != nil {
+		return variables, err
+	}
+	for key := range variableTypes {
+		variable := variables[key]
+		applyBucketedVariable(&variable, bucketedVariables[key], defaultValues[key])
+		variables[key] = variable
+	}
+	return variables, nil
+}
+
 func (c *Client) AllVariables(user User) (map[string]ReadOnlyVariable, error) {
+	return c.AllVariablesCtx(c.ctx, user)
+}
+
+// AllVariablesCtx returns all variables for the user, honouring the cancellation and deadline of ctx
+// for cloud bucketing requests.
+func (c *Client) AllVariablesCtx(ctx context.Context, user User) (map[string]ReadOnlyVariable, error) {
+	user = c.enrichUser(user)
 	var (
 		httpMethod          = strings.ToUpper("Post")
 		postBody            interface{}
@@ -425,14 +677,14 @@ func (c *Client) AllVariables(user User) (map[string]ReadOnlyVariable, error) {
 	)
 	if c.IsLocalBucketing() {
 		if c.hasConfig() {
-			user, err := c.generateBucketedConfig(user)
+			config, err := c.generateBucketedConfig(user)
 			if err != nil {
 				return localVarReturnValue, err
 			}
-			return user.Variables, err
+			return c.applyOverridesToVariables(user, config.Variables), err
 		} else {
 			util.Warnf("AllFeatures called before client initialized")
-			return map[string]ReadOnlyVariable{}, nil
+			return c.applyOverridesToVariables(user, map[string]ReadOnlyVariable{}), nil
 		}
 	}
 
@@ -447,7 +699,7 @@ func (c *Client) AllVariables(user User) (map[string]ReadOnlyVariable, error) {
 	// body params
 	postBody = &populatedUser
 
-	r, rBody, err := c.performRequest(path, httpMethod, postBody, headers, queryParams)
+	r, rBody, err := c.performRequest(ctx, path, httpMethod, postBody, headers, queryParams)
 	if err != nil {
 		return localVarReturnValue, err
 	}
@@ -455,7 +707,10 @@ func (c *Client) AllVariables(user User) (map[string]ReadOnlyVariable, error) {
 	if r.StatusCode < 300 {
 		// If we succeed, return the data, otherwise pass on to decode error.
 		err = decode(&localVarReturnValue, rBody, r.Header.Get("Content-Type"))
-		return localVarReturnValue, err
+		if err != nil {
+			return localVarReturnValue, err
+		}
+		return c.applyOverridesToVariables(user, localVarReturnValue), nil
 	}
 
 	return nil, c.handleError(r, rBody)
@@ -468,14 +723,19 @@ Post events to DevCycle for user
 
 @return InlineResponse201
 */
-
 func (c *Client) Track(user User, event Event) (bool, error) {
+	return c.TrackCtx(c.ctx, user, event)
+}
+
+// TrackCtx is like Track, honouring the cancellation and deadline of ctx.
+func (c *Client) TrackCtx(ctx context.Context, user User, event Event) (bool, error) {
 	if c.DevCycleOptions.DisableCustomEventLogging {
 		return true, nil
 	}
 	if event.Type_ == "" {
 		return false, errors.New("event type is required")
 	}
+	user = c.enrichUser(user)
 
 	if c.IsLocalBucketing() {
 		if c.hasConfig() {
@@ -495,7 +755,11 @@ func (c *Client) Track(user User, event Event) (bool, error) {
 		postBody   interface{}
 	)
 
-	populatedUser := user.GetPopulatedUser(c.platformData)
+	now := c.now()
+	populatedUser := user.GetPopulatedUserWithTime(c.platformData, now)
+	if event.ClientDate.IsZero() {
+		event.ClientDate = now
+	}
 
 	events := []Event{event}
 	body := UserDataAndEventsBody{User: &populatedUser, Events: events}
@@ -508,7 +772,7 @@ func (c *Client) Track(user User, event Event) (bool, error) {
 	// body params
 	postBody = &body
 
-	r, rBody, err := c.performRequest(path, httpMethod, postBody, headers, queryParams)
+	r, rBody, err := c.performRequest(ctx, path, httpMethod, postBody, headers, queryParams)
 	if err != nil {
 		return false, err
 	}
@@ -527,6 +791,11 @@ func (c *Client) Track(user User, event Event) (bool, error) {
 }
 
 func (c *Client) FlushEvents() error {
+	return c.FlushEventsCtx(c.ctx)
+}
+
+// FlushEventsCtx flushes any queued events, abandoning in-flight requests to the events API when ctx is done.
+func (c *Client) FlushEventsCtx(ctx context.Context) error {
 	if !c.IsLocalBucketing() || !c.isInitialized {
 		return nil
 	}
@@ -535,7 +804,7 @@ func (c *Client) FlushEvents() error {
 		return nil
 	}
 
-	err := c.eventQueue.FlushEvents()
+	err := c.eventQueue.FlushEventsCtx(ctx)
 	if err != nil {
 		util.Errorf("Error flushing events: %v", err)
 	}
@@ -545,7 +814,9 @@ func (c *Client) FlushEvents() error {
 func (c *Client) SetClientCustomData(customData map[string]interface{}) error {
 	if c.IsLocalBucketing() {
 		if c.isInitialized {
-			return c.localBucketing.SetClientCustomData(customData)
+			err := c.localBucketing.SetClientCustomData(customData)
+			c.watchers.notify()
+			return err
 		} else {
 			util.Warnf("SetClientCustomData called before client initialized")
 			return nil
@@ -555,10 +826,87 @@ func (c *Client) SetClientCustomData(customData map[string]interface{}) error {
 	return errors.New("SetClientCustomData is not available in cloud bucketing mode")
 }
 
+// OptIn records that the user opted in to the feature, so they pass the opt-in filters of its targets when opt-in
+// is enabled in the project settings. The opt-in is saved in Options.OptInStore.
+func (c *Client) OptIn(user User, featureKey string) error {
+	return c.setOptIn(user, featureKey, true)
+}
+
+// OptOut removes the opt-in of the user to the feature.
+func (c *Client) OptOut(user User, featureKey string) error {
+	return c.setOptIn(user, featureKey, false)
+}
+
+func (c *Client) setOptIn(user User, featureKey string, optedIn bool) error {
+	if !c.IsLocalBucketing() {
+		return errors.New("OptIn and OptOut are not available in cloud bucketing mode")
+	}
+	if user.UserId == "" {
+		return errors.New("a user id is required to opt in to or out of a feature")
+	}
+	if !c.hasConfig() {
+		return errors.New("OptIn and OptOut require a config to find the feature")
+	}
+	featureId, err := c.localBucketing.FeatureIdForKey(featureKey)
+	if err != nil {
+		return err
+	}
+	err = c.DevCycleOptions.OptInStore.SetOptIn(user.UserId, featureId, optedIn)
+	if err != nil {
+		return err
+	}
+	c.watchers.notify()
+	return nil
+}
+
+// RolloutPreview returns the percentage of users the rollout of each target of the feature lets through, every
+// step from from until to, to check a rollout plan before it goes live. It requires local bucketing and a config.
+func (c *Client) RolloutPreview(featureKey string, from, to time.Time, step time.Duration) ([]TargetRolloutPreview, error) {
+	if !c.IsLocalBucketing() {
+		return nil, errors.New("RolloutPreview is not available in cloud bucketing mode")
+	}
+	if !c.hasConfig() {
+		return nil, errors.New("RolloutPreview requires a config to find the feature")
+	}
+	return c.localBucketing.RolloutPreview(featureKey, from, to, step)
+}
+
+// Explain evaluates the variable for the user without queuing events, and traces why they got its value: the
+// result of every filter of each target of the feature, the rollout and the distribution that picked the
+// variation. The explanation marshals to JSON, to be attached to support tickets.
+func (c *Client) Explain(userdata User, variableKey string) (*Explanation, error) {
+	if variableKey == "" {
+		return nil, errors.New("invalid key provided for call to Explain")
+	}
+	if !c.IsLocalBucketing() {
+		return nil, errors.New("Explain is not available in cloud bucketing mode")
+	}
+	if !c.hasConfig() {
+		return nil, errors.New("Explain requires a config to evaluate the variable")
+	}
+	userdata = c.enrichUser(userdata)
+	explanation, err := c.localBucketing.Explain(userdata, variableKey)
+	if err != nil {
+		return nil, err
+	}
+	if override, ok := c.overrides.forUser(userdata, variableKey); ok {
+		// The trace still shows what the config would serve without the override
+		explanation.Value = override.Value
+		explanation.Eval = EvalDetails{Reason: EvalReasonOverride}
+		explanation.Error = ""
+	}
+	return explanation, nil
+}
+
 /*
 Close the client and flush any pending events. Stop any ongoing tickers
 */
 func (c *Client) Close() (err error) {
+	c.watchers.close()
+	if c.geoIP != nil {
+		c.geoIP.Close()
+	}
+
 	if !c.IsLocalBucketing() {
 		return
 	}
@@ -592,7 +940,16 @@ func (c *Client) hasConfig() bool {
 	return c.configManager.HasConfig()
 }
 
+// now returns the time of Options.Clock.
+func (c *Client) now() time.Time {
+	if c.DevCycleOptions.Clock == nil {
+		return time.Now()
+	}
+	return c.DevCycleOptions.Clock.Now()
+}
+
 func (c *Client) performRequest(
+	ctx context.Context,
 	path string, method string,
 	postBody interface{},
 	headerParams map[string]string,
@@ -608,8 +965,14 @@ func (c *Client) performRequest(
 	// This retrying lib works by retrying as long as the bool is true and err is not nil
 	// the attempt param is auto-incremented
 	err = try.Do(func(attempt int) (bool, error) {
+		// Don't start another attempt if the caller has given up
+		if err := ctx.Err(); err != nil {
+			return false, err
+		}
+
 		var err error
 		r, err := c.prepareRequest(
+			ctx,
 			path,
 			method,
 			postBody,
@@ -627,7 +990,10 @@ func (c *Client) performRequest(
 			err = errors.New("Nil httpResponse")
 		}
 		if err != nil {
-			time.Sleep(time.Duration(exponentialBackoff(attempt)) * time.Millisecond) // wait with exponential backoff
+			// wait with exponential backoff
+			if ctxErr := backoffWithContext(ctx, attempt); ctxErr != nil {
+				return false, ctxErr
+			}
 			return attempt <= 5, err
 		}
 		responseBody, err = io.ReadAll(httpResponse.Body)
@@ -638,7 +1004,10 @@ func (c *Client) performRequest(
 		}
 
 		if err != nil {
-			time.Sleep(time.Duration(exponentialBackoff(attempt)) * time.Millisecond) // wait with exponential backoff
+			// wait with exponential backoff
+			if ctxErr := backoffWithContext(ctx, attempt); ctxErr != nil {
+				return false, ctxErr
+			}
 		}
 
 		return attempt <= 5, err // try 5 times
@@ -739,6 +1108,19 @@ func exponentialBackoff(attempt int) float64 {
 	return (delay + randomSum)
 }
 
+// backoffWithContext waits for the exponential backoff delay of the given attempt,
+// returning the context's error early if it is done before the delay elapses.
+func backoffWithContext(ctx context.Context, attempt int) error {
+	timer := time.NewTimer(time.Duration(exponentialBackoff(attempt)) * time.Millisecond)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
 // Change base path to allow switching to mocks
 func (c *Client) ChangeBasePath(path string) {
 	c.cfg.BasePath = path
@@ -750,6 +1132,7 @@ func (c *Client) SetOptions(dvcOptions Options) {
 
 // prepareRequest build the request
 func (c *Client) prepareRequest(
+	ctx context.Context,
 	path string,
 	method string,
 	postBody interface{},
@@ -796,9 +1179,9 @@ func (c *Client) prepareRequest(
 
 	// Generate a new request
 	if body != nil {
-		localVarRequest, err = http.NewRequest(method, builtURL.String(), body)
+		localVarRequest, err = http.NewRequestWithContext(ctx, method, builtURL.String(), body)
 	} else {
-		localVarRequest, err = http.NewRequest(method, builtURL.String(), nil)
+		localVarRequest, err = http.NewRequestWithContext(ctx, method, builtURL.String(), nil)
 	}
 	if err != nil {
 		return nil, err
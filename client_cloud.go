@@ -0,0 +1,79 @@
+package devcycle
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+)
+
+// cloudVariable is a variable as served by the bucketing API, which reports how it was evaluated with its own keys.
+type cloudVariable struct {
+	Type_   string          `json:"type"`
+	Value   interface{}     `json:"value"`
+	Feature string          `json:"_feature"`
+	Eval    cloudEvalReason `json:"eval"`
+}
+
+type cloudEvalReason struct {
+	Reason   string `json:"reason"`
+	Details  string `json:"details"`
+	TargetId string `json:"target_id"`
+}
+
+// cloudEvalReasons maps the bucketing API reasons without an identical EvalReason. Others are kept as they are.
+var cloudEvalReasons = map[string]EvalReason{
+	// The user matched a target and was bucketed into one of its variations by its distribution
+	"SPLIT": EvalReasonTargetingMatch,
+	// The user matched none of the targets
+	"DEFAULT": EvalReasonNoTargetMatch,
+}
+
+func (v cloudVariable) evalDetails() EvalDetails {
+	reason, ok := cloudEvalReasons[v.Eval.Reason]
+	if !ok {
+		reason = EvalReason(v.Eval.Reason)
+	}
+	if reason == "" {
+		// Older bucketing APIs don't report a reason for the variables they serve
+		reason = EvalReasonTargetingMatch
+	}
+	return EvalDetails{
+		Reason:    reason,
+		Details:   v.Eval.Details,
+		FeatureId: v.Feature,
+		TargetId:  v.Eval.TargetId,
+	}
+}
+
+// cloudVariables serves the variables of the keys in variableTypes with one request for all the variables of the
+// user. Variables the bucketing API doesn't serve keep their default values.
+func (c *Client) cloudVariables(ctx context.Context, userdata User, variables map[string]Variable, variableTypes map[string]string) (map[string]Variable, error) {
+	if len(variableTypes) == 0 {
+		return variables, nil
+	}
+	populatedUser := userdata.GetPopulatedUser(c.platformData)
+	r, body, err := c.performRequest(ctx, c.cfg.BasePath+"/v1/variables", http.MethodPost, &populatedUser, map[string]string{}, url.Values{})
+	if err == nil && r.StatusCode >= 300 {
+		err = c.handleError(r, body)
+	}
+	var served map[string]cloudVariable
+	if err == nil {
+		err = decode(&served, body, r.Header.Get("Content-Type"))
+	}
+
+	for key := range variableTypes {
+		variable := variables[key]
+		if servedVariable, ok := served[key]; err != nil {
+			variable.Eval = EvalDetails{Reason: EvalReasonError}
+		} else if !ok || servedVariable.Value == nil {
+			variable.Eval = EvalDetails{Reason: EvalReasonVariableNotFound}
+		} else {
+			applyBucketedVariable(&variable, Variable{
+				BaseVariable: BaseVariable{Key: key, Type_: servedVariable.Type_, Value: servedVariable.Value},
+				Eval:         servedVariable.evalDetails(),
+			}, variable.DefaultValue)
+		}
+		variables[key] = variable
+	}
+	return variables, err
+}
@@ -0,0 +1,38 @@
+package devcycle
+
+// enrichUser sets the country of users that have an IP but no country from the GeoIP database, and their
+// region and city when Options.GeoIPCustomData is set. Custom data is copied before it is modified.
+func (c *Client) enrichUser(user User) User {
+	if c.geoIP == nil || user.IP == "" || user.Country != "" {
+		return user
+	}
+	location, found := c.geoIP.Lookup(user.IP)
+	if !found {
+		return user
+	}
+	user.Country = location.Country
+	if !c.DevCycleOptions.GeoIPCustomData {
+		return user
+	}
+
+	additions := make(map[string]interface{}, 2)
+	for key, value := range map[string]string{"region": location.Region, "city": location.City} {
+		_, isCustom := user.CustomData[key]
+		_, isPrivate := user.PrivateCustomData[key]
+		if value != "" && !isCustom && !isPrivate {
+			additions[key] = value
+		}
+	}
+	if len(additions) == 0 {
+		return user
+	}
+	customData := make(map[string]interface{}, len(user.CustomData)+len(additions))
+	for key, value := range user.CustomData {
+		customData[key] = value
+	}
+	for key, value := range additions {
+		customData[key] = value
+	}
+	user.CustomData = customData
+	return user
+}
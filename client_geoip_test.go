@@ -0,0 +1,76 @@
+package devcycle
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// geoFilters target users in Montreal, Canada.
+const geoFilters = `{"type": "user", "subType": "country", "comparator": "=", "values": ["CA"]},
+	{"type": "user", "subType": "customData", "dataKey": "city", "dataKeyType": "String", "comparator": "=", "values": ["Montreal"]}`
+
+func newGeoIPClient(t *testing.T, customData bool) *Client {
+	c, err := NewClient(test_environmentKey, &Options{
+		Offline:              true,
+		BootstrapConfig:      targetedConfig(t, geoFilters),
+		DisableEventDelivery: true,
+		GeoIPDatabasePath:    "testdata/geoip-test.mmdb",
+		GeoIPCustomData:      customData,
+	})
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = c.Close() })
+	return c
+}
+
+func TestClient_GeoIP_TargetsByIPLocation(t *testing.T) {
+	c := newGeoIPClient(t, true)
+
+	value, err := c.StringVariable(User{UserId: "geo-user", IP: "203.0.113.42"}, "string-var", "off")
+	require.NoError(t, err)
+	require.Equal(t, "on", value)
+
+	// Users outside the targeted location, or without an IP, are not targeted
+	for _, user := range []User{
+		{UserId: "geo-user", IP: "198.51.100.1"},
+		{UserId: "geo-user", IP: "8.8.8.8"},
+		{UserId: "geo-user"},
+	} {
+		value, err = c.StringVariable(user, "string-var", "off")
+		require.NoError(t, err)
+		require.Equal(t, "off", value, user.IP)
+	}
+
+	// Users with a country keep it, and their custom data takes precedence
+	value, err = c.StringVariable(User{UserId: "geo-user", IP: "203.0.113.42", Country: "US"}, "string-var", "off")
+	require.NoError(t, err)
+	require.Equal(t, "off", value)
+	value, err = c.StringVariable(User{UserId: "geo-user", IP: "203.0.113.42", CustomData: map[string]interface{}{"city": "Quebec"}}, "string-var", "off")
+	require.NoError(t, err)
+	require.Equal(t, "off", value)
+}
+
+func TestClient_GeoIP_EnrichUser(t *testing.T) {
+	c := newGeoIPClient(t, false)
+	customData := map[string]interface{}{"plan": "pro"}
+	user := c.enrichUser(User{UserId: "geo-user", IP: "203.0.113.42", CustomData: customData})
+	require.Equal(t, "CA", user.Country)
+	require.Equal(t, map[string]interface{}{"plan": "pro"}, user.CustomData)
+
+	c = newGeoIPClient(t, true)
+	user = c.enrichUser(User{UserId: "geo-user", IP: "203.0.113.42", CustomData: customData})
+	require.Equal(t, map[string]interface{}{"plan": "pro", "region": "QC", "city": "Montreal"}, user.CustomData)
+	require.Equal(t, map[string]interface{}{"plan": "pro"}, customData)
+
+	user = c.enrichUser(User{UserId: "geo-user", IP: "203.0.113.42", PrivateCustomData: map[string]interface{}{"city": "Laval"}})
+	require.Equal(t, map[string]interface{}{"region": "QC"}, user.CustomData)
+}
+
+func TestClient_GeoIP_MissingDatabase(t *testing.T) {
+	_, err := NewClient(test_environmentKey, &Options{
+		Offline:           true,
+		BootstrapConfig:   []byte(test_variable_types_config),
+		GeoIPDatabasePath: "testdata/missing.mmdb",
+	})
+	require.Error(t, err)
+}
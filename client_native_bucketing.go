@@ -2,7 +2,6 @@ package devcycle
 
 import (
 	"fmt"
-	"sync"
 	"time"
 
 	"github.com/BIwashi/go-server-sdk/v2/util"
@@ -30,26 +29,33 @@ func (c *Client) setLBClient(sdkKey string, options *Options) error {
 type NativeLocalBucketing struct {
 	sdkKey       string
 	options      *Options
-	configMutex  sync.RWMutex
 	platformData *api.PlatformData
+	engine       *bucketing.Engine
 	eventQueue   *bucketing.EventQueue
 }
 
 func NewNativeLocalBucketing(sdkKey string, platformData *api.PlatformData, options *Options) (*NativeLocalBucketing, error) {
-	eq, err := bucketing.NewEventQueue(sdkKey, options.eventQueueOptions(), platformData)
+	engine, err := bucketing.NewEngine(sdkKey, options.eventQueueOptions(), platformData)
 	if err != nil {
 		return nil, err
 	}
+	engine.SetOptInStore(options.OptInStore)
+	engine.SetBucketingKey(options.BucketingKey)
+	engine.SetClock(options.Clock)
+	if options.EnableEdgeDB {
+		engine.SetUserDataStore(options.UserDataStore)
+	}
 	return &NativeLocalBucketing{
 		sdkKey:       sdkKey,
 		options:      options,
 		platformData: platformData,
-		eventQueue:   eq,
+		engine:       engine,
+		eventQueue:   engine.EventQueue(),
 	}, err
 }
 
 func (n *NativeLocalBucketing) StoreConfig(configJSON []byte, eTag string) error {
-	err := bucketing.SetConfig(configJSON, n.sdkKey, eTag, n.eventQueue)
+	err := n.engine.SetConfig(configJSON, eTag)
 	if err != nil {
 		return fmt.Errorf("Error parsing config: %w", err)
 	}
@@ -58,17 +64,48 @@ func (n *NativeLocalBucketing) StoreConfig(configJSON []byte, eTag string) error
 
 func (n *NativeLocalBucketing) GenerateBucketedConfigForUser(user User) (ret *BucketedUserConfig, err error) {
 	populatedUser := user.GetPopulatedUserWithTime(n.platformData, DEFAULT_USER_TIME)
-	clientCustomData := bucketing.GetClientCustomData(n.sdkKey)
-	return bucketing.GenerateBucketedConfig(n.sdkKey, populatedUser, clientCustomData)
+	return n.engine.GenerateBucketedConfig(populatedUser)
 }
 
 func (n *NativeLocalBucketing) SetClientCustomData(customData map[string]interface{}) error {
-	bucketing.SetClientCustomData(n.sdkKey, customData)
+	n.engine.SetClientCustomData(customData)
 	return nil
 }
 
+// FeatureIdForKey returns the id of the feature with the key in the current config.
+func (n *NativeLocalBucketing) FeatureIdForKey(featureKey string) (string, error) {
+	return n.engine.FeatureIdForKey(featureKey)
+}
+
+// RolloutPreview returns the rollout percentages of the targets of the feature over time in the current config.
+func (n *NativeLocalBucketing) RolloutPreview(featureKey string, from, to time.Time, step time.Duration) ([]TargetRolloutPreview, error) {
+	return n.engine.RolloutPreview(featureKey, from, to, step)
+}
+
+// Explain traces the evaluation of the variable for the user in the current config.
+func (n *NativeLocalBucketing) Explain(user User, variableKey string) (*Explanation, error) {
+	populatedUser := user.GetPopulatedUserWithTime(n.platformData, DEFAULT_USER_TIME)
+	return n.engine.Explain(populatedUser, variableKey)
+}
+
 func (n *NativeLocalBucketing) Variable(user User, variableKey string, variableType string) (Variable, error) {
+	return n.variable(user, variableKey, variableType, n.engine.EvaluateVariable)
+}
+
+// VariableWithoutEvents evaluates the variable like Variable, without queuing an evaluation event.
+func (n *NativeLocalBucketing) VariableWithoutEvents(user User, variableKey string, variableType string) (Variable, error) {
+	return n.variable(user, variableKey, variableType, n.engine.EvaluateVariableWithoutEvents)
+}
 
+// VariableWithCheck evaluates the variable like Variable, and defaults it with a type mismatch when check rejects
+// its value.
+func (n *NativeLocalBucketing) VariableWithCheck(user User, variableKey string, variableType string, check func(value interface{}) error) (Variable, error) {
+	return n.variable(user, variableKey, variableType, func(user api.PopulatedUser, variableKey string, variableType string) (bucketing.VariableEvaluation, error) {
+		return n.engine.EvaluateVariableWithCheck(user, variableKey, variableType, check)
+	})
+}
+
+func (n *NativeLocalBucketing) variable(user User, variableKey string, variableType string, evaluate func(api.PopulatedUser, string, string) (bucketing.VariableEvaluation, error)) (Variable, error) {
 	defaultVar := Variable{
 		BaseVariable: api.BaseVariable{
 			Key:   variableKey,
@@ -78,26 +115,55 @@ func (n *NativeLocalBucketing) Variable(user User, variableKey string, variableT
 		DefaultValue: nil,
 		IsDefaulted:  true,
 	}
-	clientCustomData := bucketing.GetClientCustomData(n.sdkKey)
 	populatedUser := user.GetPopulatedUserWithTime(n.platformData, DEFAULT_USER_TIME)
 
-	resultVariableType, resultValue, err := bucketing.VariableForUser(n.sdkKey, populatedUser, variableKey, variableType, n.eventQueue, clientCustomData)
+	evaluation, err := evaluate(populatedUser, variableKey, variableType)
 	if err != nil {
+		defaultVar.Eval = evaluation.EvalDetails()
 		return defaultVar, nil
 	}
 
 	return Variable{
 		BaseVariable: api.BaseVariable{
 			Key:   variableKey,
-			Type_: resultVariableType,
-			Value: resultValue,
+			Type_: evaluation.Type,
+			Value: evaluation.Value,
 		},
 		IsDefaulted: false,
+		Eval:        evaluation.EvalDetails(),
 	}, nil
 }
 
+func (n *NativeLocalBucketing) Variables(user User, variableTypes map[string]string) (map[string]Variable, error) {
+	populatedUser := user.GetPopulatedUserWithTime(n.platformData, DEFAULT_USER_TIME)
+
+	evaluations, err := n.engine.EvaluateVariables(populatedUser, variableTypes)
+	if err != nil {
+		return nil, err
+	}
+
+	variables := make(map[string]Variable, len(evaluations))
+	for variableKey, evaluation := range evaluations {
+		variable := Variable{
+			BaseVariable: api.BaseVariable{
+				Key:   variableKey,
+				Type_: variableTypes[variableKey],
+			},
+			IsDefaulted: true,
+			Eval:        evaluation.EvalDetails(),
+		}
+		if evaluation.Reason == api.EvalReasonTargetingMatch || evaluation.Reason == api.EvalReasonHoldout {
+			variable.Type_ = evaluation.Type
+			variable.Value = evaluation.Value
+			variable.IsDefaulted = false
+		}
+		variables[variableKey] = variable
+	}
+	return variables, nil
+}
+
 func (n *NativeLocalBucketing) Close() {
-	err := n.eventQueue.Close()
+	err := n.engine.Close()
 	if err != nil {
 		util.Errorf("Error closing event queue: %v", err)
 	}
@@ -0,0 +1,185 @@
+package devcycle
+
+import (
+	"errors"
+	"sort"
+	"sync"
+
+	"github.com/BIwashi/go-server-sdk/v2/util"
+)
+
+// OverrideMatcher selects the users an override applies to. A nil matcher applies the override to every user.
+type OverrideMatcher func(user User) bool
+
+// MatchUserIds returns an OverrideMatcher for the users with one of the given user ids.
+func MatchUserIds(userIds ...string) OverrideMatcher {
+	ids := make(map[string]bool, len(userIds))
+	for _, id := range userIds {
+		ids[id] = true
+	}
+	return func(user User) bool {
+		return ids[user.UserId]
+	}
+}
+
+// Override forces the value of a variable for the users selected by Matcher.
+type Override struct {
+	Key     string
+	Type_   string
+	Value   interface{}
+	Matcher OverrideMatcher
+}
+
+func (o Override) matches(user User) bool {
+	return o.Matcher == nil || o.Matcher(user)
+}
+
+type overrideStore struct {
+	mutex     sync.RWMutex
+	overrides map[string]Override
+}
+
+func newOverrideStore() *overrideStore {
+	return &overrideStore{overrides: make(map[string]Override)}
+}
+
+func (s *overrideStore) set(override Override) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.overrides[override.Key] = override
+}
+
+func (s *overrideStore) clear(key string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	delete(s.overrides, key)
+}
+
+func (s *overrideStore) list() []Override {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	overrides := make([]Override, 0, len(s.overrides))
+	for _, override := range s.overrides {
+		overrides = append(overrides, override)
+	}
+	sort.Slice(overrides, func(i, j int) bool {
+		return overrides[i].Key < overrides[j].Key
+	})
+	return overrides
+}
+
+// forUser returns the override for the variable key that applies to the user, if any.
+func (s *overrideStore) forUser(user User, key string) (Override, bool) {
+	s.mutex.RLock()
+	override, ok := s.overrides[key]
+	s.mutex.RUnlock()
+	if !ok || !override.matches(user) {
+		return Override{}, false
+	}
+	return override, true
+}
+
+// allForUser returns every override that applies to the user, keyed by variable key.
+func (s *overrideStore) allForUser(user User) map[string]Override {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	overrides := make(map[string]Override)
+	for key, override := range s.overrides {
+		if override.matches(user) {
+			overrides[key] = override
+		}
+	}
+	return overrides
+}
+
+/*
+SetOverride - Force the value of a variable for the users selected by matcher, or for every user if matcher is nil.
+Overrides are consulted before local or cloud bucketing and replace any previous override for the same key.
+
+  - @param key Variable key
+
+  - @param value Value to serve, of type Boolean, Number, String, or JSON
+
+  - @param matcher Selects the users the override applies to
+*/
+func (c *Client) SetOverride(key string, value interface{}, matcher OverrideMatcher) error {
+	if key == "" {
+		return errors.New("invalid key provided for call to SetOverride")
+	}
+	if value == nil {
+		return errors.New("a value is required for call to SetOverride")
+	}
+	convertedValue := convertDefaultValueType(value)
+	variableType, err := variableTypeFromValue(key, convertedValue, true)
+	if err != nil {
+		return err
+	}
+	c.overrides.set(Override{Key: key, Type_: variableType, Value: convertedValue, Matcher: matcher})
+	c.watchers.notify()
+	return nil
+}
+
+// ClearOverride removes the override for the variable key, if any.
+func (c *Client) ClearOverride(key string) {
+	c.overrides.clear(key)
+	c.watchers.notify()
+}
+
+// ListOverrides returns the overrides set on the client, sorted by variable key.
+func (c *Client) ListOverrides() []Override {
+	return c.overrides.list()
+}
+
+// applyOverride serves the override for the variable to the user, if one applies and its value has the
+// type of the default value.
+func (c *Client) applyOverride(user User, variable *Variable) bool {
+	override, ok := c.overrides.forUser(user, variable.Key)
+	if !ok {
+		return false
+	}
+	if variable.DefaultValue != nil && !compareTypes(override.Value, variable.DefaultValue) {
+		util.Warnf("Ignoring override for variable %s. Expected type %s, got %s", variable.Key, variable.Type_, override.Type_)
+		return false
+	}
+	variable.Type_ = override.Type_
+	variable.Value = override.Value
+	variable.IsDefaulted = false
+	variable.Eval = EvalDetails{Reason: EvalReasonOverride}
+	return true
+}
+
+// applyOverridesToVariables replaces the variables served to the user with the overrides that apply to them.
+func (c *Client) applyOverridesToVariables(user User, variables map[string]ReadOnlyVariable) map[string]ReadOnlyVariable {
+	overrides := c.overrides.allForUser(user)
+	if len(overrides) == 0 {
+		return variables
+	}
+	if variables == nil {
+		variables = make(map[string]ReadOnlyVariable, len(overrides))
+	}
+	for key, override := range overrides {
+		variable := variables[key]
+		variable.Key = key
+		variable.Type_ = override.Type_
+		variable.Value = override.Value
+		variables[key] = variable
+	}
+	return variables
+}
+
+// applyOverridesToFeatures marks the features owning an overridden variable in the user's bucketed config.
+func (c *Client) applyOverridesToFeatures(user User, config *BucketedUserConfig) {
+	overrides := c.overrides.allForUser(user)
+	for key := range overrides {
+		featureVariation, ok := config.VariableVariationMap[key]
+		if !ok {
+			continue
+		}
+		for featureKey, feature := range config.Features {
+			if feature.Id == featureVariation.Feature {
+				feature.EvalReason = string(EvalReasonOverride)
+				config.Features[featureKey] = feature
+			}
+		}
+	}
+}
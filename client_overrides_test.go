@@ -0,0 +1,134 @@
+package devcycle
+
+import (
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jarcoal/httpmock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClient_Overrides_Local(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+	httpCustomConfigMock(test_environmentKey, 200, test_variable_types_config)
+
+	c, err := NewClient(test_environmentKey, &Options{})
+	require.NoError(t, err)
+	qaUser := User{UserId: "qa"}
+	otherUser := User{UserId: "other"}
+
+	require.NoError(t, c.SetOverride("string-var", "forced", MatchUserIds("qa")))
+	require.NoError(t, c.SetOverride("bool-var", false, nil))
+
+	variable, err := c.Variable(qaUser, "string-var", "default")
+	require.NoError(t, err)
+	require.Equal(t, "forced", variable.Value)
+	require.False(t, variable.IsDefaulted)
+	require.Equal(t, EvalDetails{Reason: EvalReasonOverride}, variable.Eval)
+
+	variable, err = c.Variable(otherUser, "string-var", "default")
+	require.NoError(t, err)
+	require.Equal(t, "on", variable.Value)
+	require.Equal(t, EvalReasonTargetingMatch, variable.Eval.Reason)
+
+	value, err := c.BoolVariable(otherUser, "bool-var", true)
+	require.NoError(t, err)
+	require.False(t, value)
+
+	// An override of a different type than the default value is ignored
+	variable, err = c.Variable(qaUser, "string-var", 1)
+	require.NoError(t, err)
+	require.True(t, variable.IsDefaulted)
+	require.Equal(t, EvalReasonTypeMismatch, variable.Eval.Reason)
+
+	variables, err := c.Variables(qaUser, map[string]interface{}{"string-var": "default", "float-var": 1})
+	require.NoError(t, err)
+	require.Equal(t, EvalReasonOverride, variables["string-var"].Eval.Reason)
+	require.Equal(t, 2.5, variables["float-var"].Value)
+
+	allVariables, err := c.AllVariables(qaUser)
+	require.NoError(t, err)
+	require.Equal(t, "forced", allVariables["string-var"].Value)
+	require.Equal(t, false, allVariables["bool-var"].Value)
+	require.Equal(t, 42.0, allVariables["int-var"].Value)
+
+	features, err := c.AllFeatures(qaUser)
+	require.NoError(t, err)
+	require.Equal(t, string(EvalReasonOverride), features["variable-types"].EvalReason)
+
+	require.Equal(t, []string{"bool-var", "string-var"}, overrideKeys(c.ListOverrides()))
+	c.ClearOverride("string-var")
+	require.Equal(t, []string{"bool-var"}, overrideKeys(c.ListOverrides()))
+
+	variable, err = c.Variable(qaUser, "string-var", "default")
+	require.NoError(t, err)
+	require.Equal(t, "on", variable.Value)
+}
+
+func TestClient_Overrides_NotCountedAsEvaluated(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+	httpCustomConfigMock(test_environmentKey, 200, test_variable_types_config)
+	var eventBodies []string
+	httpmock.RegisterResponder("POST", "https://events.devcycle.com/v1/events/batch",
+		func(req *http.Request) (*http.Response, error) {
+			body, err := io.ReadAll(req.Body)
+			require.NoError(t, err)
+			eventBodies = append(eventBodies, string(body))
+			return httpmock.NewStringResponse(201, `{}`), nil
+		})
+
+	c, err := NewClient(test_environmentKey, &Options{})
+	require.NoError(t, err)
+	require.NoError(t, c.SetOverride("string-var", "forced", nil))
+
+	user := User{UserId: "j_test"}
+	_, err = c.Variable(user, "string-var", "default")
+	require.NoError(t, err)
+	_, err = c.Variable(user, "bool-var", false)
+	require.NoError(t, err)
+
+	// Aggregate events are processed asynchronously, string-var would be queued before bool-var
+	require.Eventually(t, func() bool {
+		require.NoError(t, c.FlushEvents())
+		return strings.Contains(strings.Join(eventBodies, ""), `"target":"bool-var"`)
+	}, 10*time.Second, 10*time.Millisecond)
+	require.NotContains(t, strings.Join(eventBodies, ""), `"target":"string-var"`)
+}
+
+func TestClient_Overrides_Cloud(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	c, err := NewClient(test_environmentKey, &Options{EnableCloudBucketing: true})
+	require.NoError(t, err)
+	require.NoError(t, c.SetOverride("test", true, nil))
+
+	variable, err := c.Variable(User{UserId: "j_test"}, "test", false)
+	require.NoError(t, err)
+	require.Equal(t, true, variable.Value)
+	require.Equal(t, EvalReasonOverride, variable.Eval.Reason)
+	require.Zero(t, httpmock.GetTotalCallCount())
+}
+
+func TestClient_SetOverride_InvalidValue(t *testing.T) {
+	c, err := NewClient(test_environmentKey, &Options{EnableCloudBucketing: true})
+	require.NoError(t, err)
+
+	require.Error(t, c.SetOverride("", "value", nil))
+	require.Error(t, c.SetOverride("key", nil, nil))
+	require.ErrorIs(t, c.SetOverride("key", []string{"not", "json"}, nil), ErrInvalidDefaultValue)
+	require.Empty(t, c.ListOverrides())
+}
+
+func overrideKeys(overrides []Override) []string {
+	keys := make([]string, 0, len(overrides))
+	for _, override := range overrides {
+		keys = append(keys, override.Key)
+	}
+	return keys
+}
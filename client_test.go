@@ -1,6 +1,8 @@
 package devcycle
 
 import (
+	"context"
+	"encoding/json"
 	"flag"
 	"fmt"
 	"github.com/BIwashi/go-server-sdk/v2/util"
@@ -10,6 +12,8 @@ import (
 	"net/http"
 	"os"
 	"reflect"
+	"regexp"
+	"strings"
 	"sync/atomic"
 	"testing"
 	"time"
@@ -31,6 +35,118 @@ func TestClient_AllFeatures_Local(t *testing.T) {
 	fmt.Println(features)
 }
 
+func TestClient_AllFeatures_BucketingKey(t *testing.T) {
+	c, err := NewClient(test_environmentKey, &Options{
+		Offline:              true,
+		BootstrapConfig:      []byte(test_variable_types_config),
+		DisableEventDelivery: true,
+		BucketingKey:         "company",
+	})
+	require.NoError(t, err)
+	defer c.Close()
+
+	features, err := c.AllFeatures(User{UserId: "j_test", CustomData: map[string]interface{}{"company": "acme"}})
+	require.NoError(t, err)
+	require.NotEmpty(t, features)
+	for _, feature := range features {
+		require.Equal(t, "company", feature.BucketingKey)
+	}
+
+	features, err = c.AllFeatures(User{UserId: "j_test"})
+	require.NoError(t, err)
+	for _, feature := range features {
+		require.Equal(t, "user_id", feature.BucketingKey)
+	}
+}
+
+type fixedClock time.Time
+
+func (c fixedClock) Now() time.Time {
+	return time.Time(c)
+}
+
+func TestClient_Clock_RolloutPreview(t *testing.T) {
+	// Schedule the variable types feature for March 2024
+	config := strings.Replace(test_variable_types_config, `"distribution"`,
+		`"rollout": {"type": "schedule", "startDate": "2024-03-01T00:00:00Z"}, "distribution"`, 1)
+	require.Contains(t, config, `"rollout"`)
+	newClient := func(now time.Time) *Client {
+		c, err := NewClient(test_environmentKey, &Options{
+			Offline:              true,
+			BootstrapConfig:      []byte(config),
+			DisableEventDelivery: true,
+			Clock:                fixedClock(now),
+		})
+		require.NoError(t, err)
+		t.Cleanup(func() { _ = c.Close() })
+		return c
+	}
+
+	before := newClient(time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC))
+	value, err := before.StringVariable(User{UserId: "j_test"}, "string-var", "off")
+	require.NoError(t, err)
+	require.Equal(t, "off", value)
+	after := newClient(time.Date(2024, 4, 1, 0, 0, 0, 0, time.UTC))
+	value, err = after.StringVariable(User{UserId: "j_test"}, "string-var", "off")
+	require.NoError(t, err)
+	require.Equal(t, "on", value)
+
+	from := time.Date(2024, 2, 29, 0, 0, 0, 0, time.UTC)
+	previews, err := before.RolloutPreview("variable-types", from, from.Add(48*time.Hour), 24*time.Hour)
+	require.NoError(t, err)
+	require.Equal(t, []TargetRolloutPreview{{
+		TargetId: "621642332ea68943c8833c4d",
+		Points: []RolloutPoint{
+			{Time: from, Percentage: 0},
+			{Time: from.Add(24 * time.Hour), Percentage: 0},
+			{Time: from.Add(48 * time.Hour), Percentage: 1},
+		},
+	}}, previews)
+
+	_, err = before.RolloutPreview("no-such-feature", from, from, time.Hour)
+	require.Error(t, err)
+	cloud, err := NewClient(test_environmentKey, &Options{EnableCloudBucketing: true})
+	require.NoError(t, err)
+	_, err = cloud.RolloutPreview("variable-types", from, from, time.Hour)
+	require.Error(t, err)
+}
+
+func TestClient_Explain(t *testing.T) {
+	c, err := NewClient(test_environmentKey, &Options{
+		Offline:              true,
+		BootstrapConfig:      []byte(test_variable_types_config),
+		DisableEventDelivery: true,
+	})
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = c.Close() })
+
+	user := User{UserId: "j_test"}
+	explanation, err := c.Explain(user, "string-var")
+	require.NoError(t, err)
+	require.Equal(t, "on", explanation.Value)
+	require.Equal(t, EvalReasonTargetingMatch, explanation.Eval.Reason)
+	require.Equal(t, "variable-types", explanation.FeatureKey)
+	require.Len(t, explanation.Targets, 1)
+	require.True(t, explanation.Targets[0].Selected)
+	require.NotNil(t, explanation.Targets[0].Distribution)
+	_, err = json.Marshal(explanation)
+	require.NoError(t, err)
+
+	require.NoError(t, c.SetOverride("string-var", "forced", nil))
+	explanation, err = c.Explain(user, "string-var")
+	require.NoError(t, err)
+	require.Equal(t, "forced", explanation.Value)
+	require.Equal(t, EvalReasonOverride, explanation.Eval.Reason)
+	require.True(t, explanation.Targets[0].Selected)
+
+	_, err = c.Explain(user, "")
+	require.Error(t, err)
+	cloud, err := NewClient(test_environmentKey, &Options{EnableCloudBucketing: true})
+	require.NoError(t, err)
+	_, err = cloud.Explain(user, "string-var")
+	require.Error(t, err)
+}
+
 func TestClient_AllVariablesLocal(t *testing.T) {
 	httpmock.Activate()
 	defer httpmock.DeactivateAndReset()
@@ -97,6 +213,212 @@ func TestClient_VariableCloud(t *testing.T) {
 	fmt.Println(variableValue)
 }
 
+func TestClient_VariableCtxCloud_CancelledDuringRetries(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+	httpmock.RegisterResponder("POST", "https://bucketing-api.devcycle.com/v1/variables/test",
+		httpmock.NewStringResponder(http.StatusInternalServerError, `{"message": "unavailable"}`))
+
+	c, err := NewClient(test_environmentKey, &Options{EnableCloudBucketing: true})
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	variable, err := c.VariableCtx(ctx, User{UserId: "j_test"}, "test", true)
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+	require.Less(t, time.Since(start), time.Second)
+	require.True(t, variable.IsDefaulted)
+	require.Equal(t, true, variable.Value)
+	require.Equal(t, EvalReasonError, variable.Eval.Reason)
+}
+
+func TestClient_VariableLocal_EvalReasons(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+	httpCustomConfigMock(test_environmentKey, 200, test_variable_types_config)
+
+	c, err := NewClient(test_environmentKey, &Options{})
+	require.NoError(t, err)
+	user := User{UserId: "j_test"}
+
+	variable, err := c.Variable(user, "string-var", "default")
+	require.NoError(t, err)
+	require.Equal(t, EvalDetails{
+		Reason:       EvalReasonTargetingMatch,
+		FeatureId:    "6216422850294da359385e8b",
+		TargetId:     "621642332ea68943c8833c4d",
+		VariationKey: "variation-on",
+	}, variable.Eval)
+
+	variable, err = c.Variable(user, "string-var", 1)
+	require.NoError(t, err)
+	require.True(t, variable.IsDefaulted)
+	require.Equal(t, EvalReasonTypeMismatch, variable.Eval.Reason)
+
+	variable, err = c.Variable(user, "missing-var", "default")
+	require.NoError(t, err)
+	require.True(t, variable.IsDefaulted)
+	require.Equal(t, EvalReasonVariableNotFound, variable.Eval.Reason)
+}
+
+func TestClient_VariableCloud_EvalReasons(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+	httpBucketingAPIMock()
+	httpmock.RegisterResponder("POST", "https://bucketing-api.devcycle.com/v1/variables/missing",
+		httpmock.NewStringResponder(http.StatusNotFound, `{"message": "Variable not found"}`))
+	httpmock.RegisterResponder("POST", "https://bucketing-api.devcycle.com/v1/variables/split",
+		httpmock.NewStringResponder(http.StatusOK, `{"_id": "614ef6ea475129459160721b", "key": "split", "type": "String", "value": "on",
+			"_feature": "6216422850294da359385e8b",
+			"eval": {"reason": "SPLIT", "details": "Email AND App Version", "target_id": "621642332ea68943c8833c4d"}}`))
+	httpmock.RegisterResponder("POST", "https://bucketing-api.devcycle.com/v1/variables/untargeted",
+		httpmock.NewStringResponder(http.StatusOK, `{"_id": "614ef6ea475129459160721c", "key": "untargeted", "type": "Number", "value": 1,
+			"_feature": "6216422850294da359385e8c", "eval": {"reason": "DEFAULT", "details": "User Not Targeted"}}`))
+
+	c, err := NewClient(test_environmentKey, &Options{EnableCloudBucketing: true})
+	require.NoError(t, err)
+	user := User{UserId: "j_test"}
+
+	variable, err := c.Variable(user, "test", false)
+	require.NoError(t, err)
+	require.False(t, variable.IsDefaulted)
+	require.Equal(t, EvalDetails{Reason: EvalReasonTargetingMatch}, variable.Eval)
+
+	variable, err = c.Variable(user, "split", "off")
+	require.NoError(t, err)
+	require.False(t, variable.IsDefaulted)
+	require.Equal(t, EvalDetails{
+		Reason:    EvalReasonTargetingMatch,
+		Details:   "Email AND App Version",
+		FeatureId: "6216422850294da359385e8b",
+		TargetId:  "621642332ea68943c8833c4d",
+	}, variable.Eval)
+
+	variable, err = c.Variable(user, "untargeted", 0)
+	require.NoError(t, err)
+	require.Equal(t, EvalDetails{
+		Reason:    EvalReasonNoTargetMatch,
+		Details:   "User Not Targeted",
+		FeatureId: "6216422850294da359385e8c",
+	}, variable.Eval)
+
+	variable, err = c.Variable(user, "split", 1)
+	require.NoError(t, err)
+	require.True(t, variable.IsDefaulted)
+	require.Equal(t, EvalDetails{
+		Reason:    EvalReasonTypeMismatch,
+		Details:   "Email AND App Version",
+		FeatureId: "6216422850294da359385e8b",
+		TargetId:  "621642332ea68943c8833c4d",
+	}, variable.Eval)
+
+	variable, err = c.Variable(user, "test", "default")
+	require.NoError(t, err)
+	require.True(t, variable.IsDefaulted)
+	require.Equal(t, EvalDetails{Reason: EvalReasonTypeMismatch}, variable.Eval)
+
+	variable, err = c.Variable(user, "missing", "default")
+	require.NoError(t, err)
+	require.True(t, variable.IsDefaulted)
+	require.Equal(t, EvalDetails{Reason: EvalReasonVariableNotFound}, variable.Eval)
+}
+
+func TestClient_Variables_Local(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+	httpCustomConfigMock(test_environmentKey, 200, test_variable_types_config)
+
+	c, err := NewClient(test_environmentKey, &Options{})
+	require.NoError(t, err)
+	user := User{UserId: "j_test"}
+
+	variables, err := c.Variables(user, map[string]interface{}{
+		"bool-var":    false,
+		"string-var":  "off",
+		"float-var":   1,
+		"int-var":     "wrong type",
+		"missing-var": "default",
+	})
+	require.NoError(t, err)
+	require.Len(t, variables, 5)
+
+	single, err := c.Variable(user, "string-var", "off")
+	require.NoError(t, err)
+	require.Equal(t, single, variables["string-var"])
+
+	require.Equal(t, true, variables["bool-var"].Value)
+	require.False(t, variables["bool-var"].IsDefaulted)
+	require.Equal(t, 2.5, variables["float-var"].Value)
+
+	require.True(t, variables["int-var"].IsDefaulted)
+	require.Equal(t, "wrong type", variables["int-var"].Value)
+	require.Equal(t, EvalReasonTypeMismatch, variables["int-var"].Eval.Reason)
+
+	require.True(t, variables["missing-var"].IsDefaulted)
+	require.Equal(t, "default", variables["missing-var"].Value)
+	require.Equal(t, EvalReasonVariableNotFound, variables["missing-var"].Eval.Reason)
+
+	_, err = c.Variables(user, map[string]interface{}{"": "default"})
+	require.Error(t, err)
+}
+
+func TestClient_Variables_Cloud(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+	httpmock.RegisterResponder("POST", "https://bucketing-api.devcycle.com/v1/variables",
+		httpmock.NewStringResponder(http.StatusOK, `{
+			"test": {"_id": "614ef6ea475129459160721a", "key": "test", "type": "Boolean", "value": true,
+				"_feature": "6216422850294da359385e8b", "eval": {"reason": "TARGETING_MATCH", "details": "All Users", "target_id": "621642332ea68943c8833c4d"}},
+			"string-var": {"_id": "614ef6ea475129459160721b", "key": "string-var", "type": "String", "value": "on"}
+		}`))
+
+	c, err := NewClient(test_environmentKey, &Options{EnableCloudBucketing: true})
+	require.NoError(t, err)
+
+	variables, err := c.Variables(User{UserId: "j_test"}, map[string]interface{}{"test": false})
+	require.NoError(t, err)
+	require.Len(t, variables, 1)
+	require.Equal(t, "test", variables["test"].Key)
+
+	// All the variables are served by a single request, and checked against their defaults
+	httpmock.ZeroCallCounters()
+	variables, err = c.Variables(User{UserId: "j_test"}, map[string]interface{}{
+		"test":        false,
+		"string-var":  1,
+		"missing-var": "default",
+	})
+	require.NoError(t, err)
+	require.Equal(t, 1, httpmock.GetTotalCallCount())
+
+	require.False(t, variables["test"].IsDefaulted)
+	require.Equal(t, true, variables["test"].Value)
+	require.Equal(t, EvalDetails{
+		Reason:    EvalReasonTargetingMatch,
+		Details:   "All Users",
+		FeatureId: "6216422850294da359385e8b",
+		TargetId:  "621642332ea68943c8833c4d",
+	}, variables["test"].Eval)
+
+	require.True(t, variables["string-var"].IsDefaulted)
+	require.Equal(t, 1.0, variables["string-var"].Value)
+	require.Equal(t, EvalReasonTypeMismatch, variables["string-var"].Eval.Reason)
+
+	require.True(t, variables["missing-var"].IsDefaulted)
+	require.Equal(t, "default", variables["missing-var"].Value)
+	require.Equal(t, EvalReasonVariableNotFound, variables["missing-var"].Eval.Reason)
+
+	// A failed request defaults every variable
+	httpmock.Reset()
+	httpmock.RegisterResponder("POST", "https://bucketing-api.devcycle.com/v1/variables",
+		httpmock.NewStringResponder(http.StatusUnauthorized, `{"message": "invalid sdk key"}`))
+	variables, err = c.Variables(User{UserId: "j_test"}, map[string]interface{}{"test": false})
+	require.Error(t, err)
+	require.True(t, variables["test"].IsDefaulted)
+	require.Equal(t, EvalReasonError, variables["test"].Eval.Reason)
+}
+
 func TestClient_VariableLocalNumber(t *testing.T) {
 	httpmock.Activate()
 	defer httpmock.DeactivateAndReset()
@@ -159,6 +481,7 @@ func TestClient_VariableLocalNumberWithNilDefault(t *testing.T) {
 
 	variable, err = c.Variable(user, "nonsense-key", nil)
 	fatalErr(t, err)
+	require.Equal(t, EvalReasonVariableNotFound, variable.Eval.Reason)
 	fmt.Println(variable)
 }
 
@@ -243,6 +566,12 @@ func TestClient_VariableLocalProtobuf(t *testing.T) {
 		},
 		DefaultValue: true,
 		IsDefaulted:  false,
+		Eval: EvalDetails{
+			Reason:       EvalReasonTargetingMatch,
+			FeatureId:    "6216422850294da359385e8b",
+			TargetId:     "621642332ea68943c8833c4d",
+			VariationKey: "variation-on",
+		},
 	}
 	if !reflect.DeepEqual(expected, variable) {
 		fmt.Println("got", variable)
@@ -296,6 +625,12 @@ func TestClient_VariableLocalProtobuf_UserWithCustomData(t *testing.T) {
 		},
 		DefaultValue: true,
 		IsDefaulted:  false,
+		Eval: EvalDetails{
+			Reason:       EvalReasonTargetingMatch,
+			FeatureId:    "6216422850294da359385e8b",
+			TargetId:     "621642332ea68943c8833c4d",
+			VariationKey: "variation-on",
+		},
 	}
 	if !reflect.DeepEqual(expected, variable) {
 		fmt.Println("got", variable)
@@ -322,6 +657,72 @@ func TestClient_VariableLocal_403(t *testing.T) {
 	}
 }
 
+func TestClient_Offline_BootstrapConfig(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	c, err := NewClient(test_environmentKey, &Options{
+		Offline:              true,
+		BootstrapConfig:      []byte(test_variable_types_config),
+		DisableEventDelivery: true,
+	})
+	require.NoError(t, err)
+	defer c.Close()
+
+	variable, err := c.Variable(User{UserId: "j_test"}, "string-var", "off")
+	require.NoError(t, err)
+	require.False(t, variable.IsDefaulted)
+	require.Equal(t, "on", variable.Value)
+
+	_, err = c.Track(User{UserId: "j_test"}, Event{Type_: "customEvent"})
+	require.NoError(t, err)
+	require.NoError(t, c.FlushEvents())
+	require.Zero(t, httpmock.GetTotalCallCount())
+}
+
+func TestClient_Offline_BootstrapConfigPath(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	configPath := t.TempDir() + "/config.json"
+	require.NoError(t, os.WriteFile(configPath, []byte(test_variable_types_config), 0o600))
+
+	c, err := NewClient(test_environmentKey, &Options{Offline: true, BootstrapConfigPath: configPath})
+	require.NoError(t, err)
+	defer c.Close()
+
+	value, err := c.BoolVariable(User{UserId: "j_test"}, "bool-var", false)
+	require.NoError(t, err)
+	require.True(t, value)
+	require.Zero(t, httpmock.GetTotalCallCount())
+}
+
+func TestClient_Offline_RequiresBootstrapConfig(t *testing.T) {
+	_, err := NewClient(test_environmentKey, &Options{Offline: true})
+	require.ErrorIs(t, err, ErrOfflineWithoutBootstrapConfig)
+
+	_, err = NewClient(test_environmentKey, &Options{Offline: true, BootstrapConfigPath: "does-not-exist.json"})
+	require.ErrorIs(t, err, os.ErrNotExist)
+
+	_, err = NewClient(test_environmentKey, &Options{Offline: true, BootstrapConfig: []byte("not json")})
+	require.Error(t, err)
+}
+
+func TestClient_BootstrapConfig_CDNUnavailable(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+	httpCustomConfigMock(test_environmentKey, 500, "")
+
+	c, err := NewClient(test_environmentKey, &Options{BootstrapConfig: []byte(test_variable_types_config)})
+	require.NoError(t, err)
+	defer c.Close()
+
+	value, err := c.StringVariable(User{UserId: "j_test"}, "string-var", "off")
+	require.NoError(t, err)
+	require.Equal(t, "on", value)
+	require.NotZero(t, httpmock.GetTotalCallCount())
+}
+
 func TestClient_TrackLocal_QueueEvent(t *testing.T) {
 	httpmock.Activate()
 	defer httpmock.DeactivateAndReset()
@@ -460,6 +861,23 @@ func fatalErr(t *testing.T, err error) {
 	}
 }
 
+// allFilters matches the filters of the variable types config, which target all users.
+var allFilters = regexp.MustCompile(`\{\s*"type": "all",\s*"values": \[\],\s*"filters": \[\]\s*\}`)
+
+// targetedConfig serves the variable types config only to users passing filters, in a project with the settings,
+// such as "optIn" or "edgeDB", enabled.
+func targetedConfig(t *testing.T, filters string, settings ...string) []byte {
+	t.Helper()
+	config := allFilters.ReplaceAllLiteralString(test_variable_types_config, filters)
+	require.NotEqual(t, test_variable_types_config, config)
+	for _, setting := range settings {
+		disabled := regexp.MustCompile(`"` + regexp.QuoteMeta(setting) + `": \{\s*"enabled": false`)
+		require.Regexp(t, disabled, config)
+		config = disabled.ReplaceAllLiteralString(config, `"`+setting+`": {"enabled": true`)
+	}
+	return []byte(config)
+}
+
 var (
 	benchmarkEnableEvents        bool
 	benchmarkEnableConfigUpdates bool
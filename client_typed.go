@@ -0,0 +1,152 @@
+package devcycle
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+)
+
+var ErrVariableTypeMismatch = errors.New("the variable value could not be converted to the type of the default value")
+
+// BoolVariable returns the value of a Boolean variable for the user, or defaultValue if it could not be evaluated.
+// A variable of another type returns defaultValue and an error wrapping ErrVariableTypeMismatch.
+func (c *Client) BoolVariable(user User, key string, defaultValue bool) (bool, error) {
+	return c.BoolVariableCtx(c.ctx, user, key, defaultValue)
+}
+
+// BoolVariableCtx is like BoolVariable, honouring the cancellation and deadline of ctx.
+func (c *Client) BoolVariableCtx(ctx context.Context, user User, key string, defaultValue bool) (bool, error) {
+	return typedVariableValue(ctx, c, user, key, defaultValue, nil)
+}
+
+// StringVariable returns the value of a String variable for the user, or defaultValue if it could not be evaluated.
+// A variable of another type returns defaultValue and an error wrapping ErrVariableTypeMismatch.
+func (c *Client) StringVariable(user User, key string, defaultValue string) (string, error) {
+	return c.StringVariableCtx(c.ctx, user, key, defaultValue)
+}
+
+// StringVariableCtx is like StringVariable, honouring the cancellation and deadline of ctx.
+func (c *Client) StringVariableCtx(ctx context.Context, user User, key string, defaultValue string) (string, error) {
+	return typedVariableValue(ctx, c, user, key, defaultValue, nil)
+}
+
+// Float64Variable returns the value of a Number variable for the user, or defaultValue if it could not be evaluated.
+// A variable of another type returns defaultValue and an error wrapping ErrVariableTypeMismatch.
+func (c *Client) Float64Variable(user User, key string, defaultValue float64) (float64, error) {
+	return c.Float64VariableCtx(c.ctx, user, key, defaultValue)
+}
+
+// Float64VariableCtx is like Float64Variable, honouring the cancellation and deadline of ctx.
+func (c *Client) Float64VariableCtx(ctx context.Context, user User, key string, defaultValue float64) (float64, error) {
+	return typedVariableValue(ctx, c, user, key, defaultValue, nil)
+}
+
+// IntVariable returns the value of a Number variable for the user, or defaultValue if it could not be evaluated.
+// A variable of another type, or a value with a fractional part or outside the range of int, returns defaultValue
+// and an error wrapping ErrVariableTypeMismatch.
+func (c *Client) IntVariable(user User, key string, defaultValue int) (int, error) {
+	return c.IntVariableCtx(c.ctx, user, key, defaultValue)
+}
+
+// IntVariableCtx is like IntVariable, honouring the cancellation and deadline of ctx.
+func (c *Client) IntVariableCtx(ctx context.Context, user User, key string, defaultValue int) (int, error) {
+	value, err := typedVariableValue(ctx, c, user, key, float64(defaultValue), checkInt)
+	if err != nil {
+		return defaultValue, err
+	}
+	return int(value), nil
+}
+
+// checkInt rejects numbers with a fractional part or outside the range of int. float64(math.MaxInt) rounds up
+// to 2^63, which is out of range.
+func checkInt(value interface{}) error {
+	number, _ := value.(float64)
+	if number != math.Trunc(number) || number >= math.MaxInt || number < math.MinInt {
+		return fmt.Errorf("%v is not an integer", value)
+	}
+	return nil
+}
+
+// JSONVariable returns the value of a JSON variable for the user decoded into T, or defaultValue if it could not
+// be evaluated. defaultValue must encode to a JSON object. If the variable's value cannot be decoded into T,
+// the evaluation is counted as defaulted and an error wrapping ErrVariableTypeMismatch is returned.
+func JSONVariable[T any](c *Client, user User, key string, defaultValue T) (T, error) {
+	return JSONVariableCtx(c.ctx, c, user, key, defaultValue)
+}
+
+// JSONVariableCtx is like JSONVariable, honouring the cancellation and deadline of ctx.
+func JSONVariableCtx[T any](ctx context.Context, c *Client, user User, key string, defaultValue T) (T, error) {
+	defaultJSON, err := toJSONValue(defaultValue)
+	if err != nil {
+		return defaultValue, fmt.Errorf("%w: %s: %v", ErrInvalidDefaultValue, key, err)
+	}
+	variableType, err := variableTypeFromValue(key, defaultJSON, false)
+	if err != nil {
+		return defaultValue, err
+	}
+	if variableType != "JSON" {
+		return defaultValue, fmt.Errorf("%w: %s: default value must encode to a JSON object", ErrInvalidDefaultValue, key)
+	}
+
+	var result T
+	variable, err := c.checkedVariable(ctx, user, key, defaultJSON, func(value interface{}) error {
+		var decoded T
+		rawValue, err := json.Marshal(value)
+		if err == nil {
+			err = json.Unmarshal(rawValue, &decoded)
+		}
+		result = decoded
+		return err
+	})
+	if err != nil || variable.IsDefaulted {
+		return defaultValue, err
+	}
+	return result, nil
+}
+
+func typedVariableValue[T bool | string | float64](ctx context.Context, c *Client, user User, key string, defaultValue T, check func(value interface{}) error) (T, error) {
+	variable, err := c.checkedVariable(ctx, user, key, defaultValue, check)
+	if err != nil || variable.IsDefaulted {
+		return defaultValue, err
+	}
+	value, ok := variable.Value.(T)
+	if !ok {
+		return defaultValue, fmt.Errorf("%w: %s: expected %T, got %T", ErrVariableTypeMismatch, key, defaultValue, variable.Value)
+	}
+	return value, nil
+}
+
+// checkedVariable evaluates the variable like Variable, queuing a single evaluation event. A value of another type
+// than defaultValue, or that check rejects, is defaulted and counted as defaulted, and an error wrapping
+// ErrVariableTypeMismatch is returned.
+func (c *Client) checkedVariable(ctx context.Context, user User, key string, defaultValue interface{}, check func(value interface{}) error) (Variable, error) {
+	var checkErr error
+	var recordingCheck func(value interface{}) error
+	if check != nil {
+		recordingCheck = func(value interface{}) error {
+			checkErr = check(value)
+			return checkErr
+		}
+	}
+	variable, err := c.variable(ctx, user, key, defaultValue, recordingCheck)
+	if err != nil || variable.Eval.Reason != EvalReasonTypeMismatch {
+		return variable, err
+	}
+	if checkErr != nil {
+		return variable, fmt.Errorf("%w: %s: %v", ErrVariableTypeMismatch, key, checkErr)
+	}
+	return variable, fmt.Errorf("%w: %s: expected %T", ErrVariableTypeMismatch, key, defaultValue)
+}
+
+// toJSONValue round-trips a value through encoding/json so it has the same shape as a decoded variable value.
+func toJSONValue(value interface{}) (interface{}, error) {
+	raw, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	var result interface{}
+	err = json.Unmarshal(raw, &result)
+	return result, err
+}
@@ -0,0 +1,209 @@
+package devcycle
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jarcoal/httpmock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/BIwashi/go-server-sdk/v2/api"
+)
+
+type testJSONVariable struct {
+	Name  string   `json:"name"`
+	Limit int      `json:"limit"`
+	Tags  []string `json:"tags"`
+}
+
+func TestClient_TypedVariables_Local(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+	httpCustomConfigMock(test_environmentKey, 200, test_variable_types_config)
+
+	c, err := NewClient(test_environmentKey, &Options{})
+	require.NoError(t, err)
+	user := User{UserId: "j_test"}
+
+	boolValue, err := c.BoolVariable(user, "bool-var", false)
+	require.NoError(t, err)
+	require.True(t, boolValue)
+
+	stringValue, err := c.StringVariable(user, "string-var", "off")
+	require.NoError(t, err)
+	require.Equal(t, "on", stringValue)
+
+	intValue, err := c.IntVariable(user, "int-var", 1)
+	require.NoError(t, err)
+	require.Equal(t, 42, intValue)
+
+	floatValue, err := c.Float64Variable(user, "float-var", 1)
+	require.NoError(t, err)
+	require.Equal(t, 2.5, floatValue)
+
+	jsonValue, err := JSONVariable(c, user, "json-var", testJSONVariable{Name: "default"})
+	require.NoError(t, err)
+	require.Equal(t, testJSONVariable{Name: "on", Limit: 10, Tags: []string{"a", "b"}}, jsonValue)
+}
+
+func TestClient_TypedVariables_Defaulted(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+	httpCustomConfigMock(test_environmentKey, 200, test_variable_types_config)
+
+	c, err := NewClient(test_environmentKey, &Options{})
+	require.NoError(t, err)
+	user := User{UserId: "j_test"}
+
+	boolValue, err := c.BoolVariable(user, "missing-var", true)
+	require.NoError(t, err)
+	require.True(t, boolValue)
+
+	// A String variable requested as a bool returns the default value
+	boolValue, err = c.BoolVariable(user, "string-var", true)
+	require.ErrorIs(t, err, ErrVariableTypeMismatch)
+	require.True(t, boolValue)
+
+	intValue, err := c.IntVariable(user, "float-var", 7)
+	require.ErrorIs(t, err, ErrVariableTypeMismatch)
+	require.Equal(t, 7, intValue)
+}
+
+func TestClient_IntVariable_OutOfRange(t *testing.T) {
+	// 2^63 is the first float64 above math.MaxInt
+	config := strings.Replace(test_variable_types_config, `"value": 42`, `"value": 9223372036854775808`, 1)
+	c, err := NewClient(test_environmentKey, &Options{
+		Offline:              true,
+		BootstrapConfig:      []byte(config),
+		DisableEventDelivery: true,
+	})
+	require.NoError(t, err)
+	defer c.Close()
+
+	intValue, err := c.IntVariable(User{UserId: "j_test"}, "int-var", 7)
+	require.ErrorIs(t, err, ErrVariableTypeMismatch)
+	require.Equal(t, 7, intValue)
+}
+
+func TestClient_TypedVariables_SingleEvent(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+	httpCustomConfigMock(test_environmentKey, 200, test_variable_types_config)
+	var mutex sync.Mutex
+	var events []Event
+	httpmock.RegisterResponder("POST", "https://events.devcycle.com/v1/events/batch",
+		func(req *http.Request) (*http.Response, error) {
+			var batch BatchEventsBody
+			require.NoError(t, json.NewDecoder(req.Body).Decode(&batch))
+			mutex.Lock()
+			defer mutex.Unlock()
+			for _, record := range batch.Batch {
+				events = append(events, record.Events...)
+			}
+			return httpmock.NewStringResponse(201, `{}`), nil
+		})
+
+	c, err := NewClient(test_environmentKey, &Options{})
+	require.NoError(t, err)
+	defer c.Close()
+	user := User{UserId: "j_test"}
+
+	_, err = c.IntVariable(user, "float-var", 7)
+	require.ErrorIs(t, err, ErrVariableTypeMismatch)
+	type mismatched struct {
+		Name int `json:"name"`
+	}
+	_, err = JSONVariable(c, user, "json-var", mismatched{})
+	require.ErrorIs(t, err, ErrVariableTypeMismatch)
+	_, err = c.BoolVariable(user, "string-var", false)
+	require.ErrorIs(t, err, ErrVariableTypeMismatch)
+
+	counts := func() map[string]float64 {
+		mutex.Lock()
+		defer mutex.Unlock()
+		counts := make(map[string]float64)
+		for _, event := range events {
+			counts[event.Type_+" "+event.Target] += event.Value
+		}
+		return counts
+	}
+	require.Eventually(t, func() bool {
+		require.NoError(t, c.FlushEvents())
+		return len(counts()) > 0
+	}, 10*time.Second, 10*time.Millisecond)
+	require.Equal(t, map[string]float64{
+		api.EventType_AggVariableDefaulted + " float-var":  1,
+		api.EventType_AggVariableDefaulted + " json-var":   1,
+		api.EventType_AggVariableDefaulted + " string-var": 1,
+	}, counts())
+}
+
+func TestJSONVariable_DecodeFailure(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+	httpCustomConfigMock(test_environmentKey, 200, test_variable_types_config)
+
+	c, err := NewClient(test_environmentKey, &Options{})
+	require.NoError(t, err)
+
+	type mismatched struct {
+		Name int `json:"name"`
+	}
+	defaultValue := mismatched{Name: 1}
+	value, err := JSONVariable(c, User{UserId: "j_test"}, "json-var", defaultValue)
+	require.ErrorIs(t, err, ErrVariableTypeMismatch)
+	require.Equal(t, defaultValue, value)
+}
+
+func TestJSONVariable_InvalidDefault(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+	httpCustomConfigMock(test_environmentKey, 200, test_variable_types_config)
+
+	c, err := NewClient(test_environmentKey, &Options{})
+	require.NoError(t, err)
+
+	_, err = JSONVariable(c, User{UserId: "j_test"}, "json-var", []string{"not", "an", "object"})
+	require.ErrorIs(t, err, ErrInvalidDefaultValue)
+
+	_, err = JSONVariable(c, User{UserId: "j_test"}, "json-var", "a string")
+	require.ErrorIs(t, err, ErrInvalidDefaultValue)
+}
+
+func TestClient_TypedVariablesCtx_Cloud(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+	httpBucketingAPIMock()
+
+	c, err := NewClient(test_environmentKey, &Options{EnableCloudBucketing: true})
+	require.NoError(t, err)
+	user := User{UserId: "j_test"}
+
+	value, err := c.BoolVariableCtx(context.Background(), user, "test", false)
+	require.NoError(t, err)
+	require.True(t, value)
+
+	// Every typed variable returns its default value when ctx is done before the request is made
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	value, err = c.BoolVariableCtx(ctx, user, "test", false)
+	require.ErrorIs(t, err, context.Canceled)
+	require.False(t, value)
+	str, err := c.StringVariableCtx(ctx, user, "test", "default")
+	require.ErrorIs(t, err, context.Canceled)
+	require.Equal(t, "default", str)
+	number, err := c.Float64VariableCtx(ctx, user, "test", 1.5)
+	require.ErrorIs(t, err, context.Canceled)
+	require.Equal(t, 1.5, number)
+	integer, err := c.IntVariableCtx(ctx, user, "test", 3)
+	require.ErrorIs(t, err, context.Canceled)
+	require.Equal(t, 3, integer)
+	jsonValue, err := JSONVariableCtx(ctx, c, user, "test", testJSONVariable{Name: "default"})
+	require.ErrorIs(t, err, context.Canceled)
+	require.Equal(t, testJSONVariable{Name: "default"}, jsonValue)
+}
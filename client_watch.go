@@ -0,0 +1,154 @@
+package devcycle
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sync"
+)
+
+// variableWatchers tracks the watches started by WatchVariable and wakes them up when the result of
+// an evaluation may have changed.
+type variableWatchers struct {
+	mutex    sync.Mutex
+	watchers map[*variableWatcher]struct{}
+	closed   chan struct{}
+	once     sync.Once
+}
+
+type variableWatcher struct {
+	changed chan struct{}
+}
+
+func newVariableWatchers() *variableWatchers {
+	return &variableWatchers{
+		watchers: make(map[*variableWatcher]struct{}),
+		closed:   make(chan struct{}),
+	}
+}
+
+func (w *variableWatchers) add() *variableWatcher {
+	watcher := &variableWatcher{changed: make(chan struct{}, 1)}
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	w.watchers[watcher] = struct{}{}
+	return watcher
+}
+
+func (w *variableWatchers) remove(watcher *variableWatcher) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	delete(w.watchers, watcher)
+}
+
+// notify wakes up every watcher without blocking. A watcher that has not handled its previous
+// notification yet re-evaluates once for both.
+func (w *variableWatchers) notify() {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	for watcher := range w.watchers {
+		select {
+		case watcher.changed <- struct{}{}:
+		default:
+		}
+	}
+}
+
+func (w *variableWatchers) close() {
+	w.once.Do(func() {
+		close(w.closed)
+	})
+}
+
+/*
+WatchVariable - Watch the value of a variable for user data
+
+The returned channel first receives the current variable, then receives the variable again each time a config
+update, override or client custom data change alters its value for the user. Re-evaluations do not queue
+evaluation events. The channel is closed when ctx is done or the client is closed.
+Only available in local bucketing mode.
+
+  - @param ctx context.Context - stops the watch when done.
+
+  - @param body
+
+  - @param key Variable key
+
+  - @param defaultValue Default value
+
+    -@return <-chan Variable
+*/
+func (c *Client) WatchVariable(ctx context.Context, userdata User, key string, defaultValue interface{}) (<-chan Variable, error) {
+	if !c.IsLocalBucketing() {
+		return nil, errors.New("WatchVariable is not available in cloud bucketing mode")
+	}
+	userdata = c.enrichUser(userdata)
+
+	// Registering before the first evaluation re-evaluates the variable for updates made meanwhile
+	watcher := c.watchers.add()
+	variable, err := c.Variable(userdata, key, defaultValue)
+	if err != nil {
+		c.watchers.remove(watcher)
+		return nil, err
+	}
+
+	updates := make(chan Variable, 1)
+	updates <- variable
+
+	go func() {
+		defer close(updates)
+		defer c.watchers.remove(watcher)
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-c.watchers.closed:
+				return
+			case <-watcher.changed:
+			}
+
+			updated := c.reevaluateVariable(userdata, variable, defaultValue)
+			if updated.IsDefaulted == variable.IsDefaulted && reflect.DeepEqual(updated.Value, variable.Value) {
+				continue
+			}
+			variable = updated
+
+			select {
+			case updates <- variable:
+			case <-ctx.Done():
+				return
+			case <-c.watchers.closed:
+				return
+			}
+		}
+	}()
+
+	return updates, nil
+}
+
+// reevaluateVariable evaluates a watched variable again without queuing evaluation events.
+func (c *Client) reevaluateVariable(userdata User, previous Variable, defaultValue interface{}) Variable {
+	// The default value was validated by the first evaluation
+	variableType, _ := variableTypeFromValue(previous.Key, previous.DefaultValue, true)
+	variable := Variable{
+		BaseVariable: BaseVariable{Key: previous.Key, Value: previous.DefaultValue, Type_: variableType},
+		DefaultValue: previous.DefaultValue,
+		IsDefaulted:  true,
+	}
+
+	if c.applyOverride(userdata, &variable) {
+		return variable
+	}
+	if !c.hasConfig() {
+		variable.Eval = EvalDetails{Reason: EvalReasonNotInitialized}
+		return variable
+	}
+	bucketedVariable, err := c.localBucketing.VariableWithoutEvents(userdata, variable.Key, variableType)
+	if err != nil {
+		variable.Eval = EvalDetails{Reason: EvalReasonError}
+		return variable
+	}
+	applyBucketedVariable(&variable, bucketedVariable, defaultValue)
+	return variable
+}
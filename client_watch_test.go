@@ -0,0 +1,162 @@
+package devcycle
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/BIwashi/go-server-sdk/v2/api"
+	"github.com/jarcoal/httpmock"
+	"github.com/stretchr/testify/require"
+)
+
+func receiveVariable(t *testing.T, updates <-chan Variable) Variable {
+	t.Helper()
+	select {
+	case variable, ok := <-updates:
+		require.True(t, ok, "watch channel was closed")
+		return variable
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for a variable update")
+	}
+	return Variable{}
+}
+
+func requireNoUpdate(t *testing.T, updates <-chan Variable) {
+	t.Helper()
+	select {
+	case variable := <-updates:
+		t.Fatalf("unexpected variable update: %+v", variable)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+func TestClient_WatchVariable(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+	httpCustomConfigMock(test_environmentKey, 200, test_variable_types_config)
+
+	c, err := NewClient(test_environmentKey, &Options{})
+	require.NoError(t, err)
+	defer c.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	user := User{UserId: "j_test"}
+
+	updates, err := c.WatchVariable(ctx, user, "string-var", "default")
+	require.NoError(t, err)
+	require.Equal(t, "on", receiveVariable(t, updates).Value)
+
+	changedConfig := strings.Replace(test_variable_types_config, `"value": "on"`, `"value": "changed"`, 1)
+	require.NoError(t, c.configManager.setConfig([]byte(changedConfig), "changed"))
+	variable := receiveVariable(t, updates)
+	require.Equal(t, "changed", variable.Value)
+	require.Equal(t, EvalReasonTargetingMatch, variable.Eval.Reason)
+
+	// Config updates that do not change the value for the user are not pushed
+	require.NoError(t, c.configManager.setConfig([]byte(changedConfig), "changed-again"))
+	requireNoUpdate(t, updates)
+
+	require.NoError(t, c.SetOverride("string-var", "forced", nil))
+	variable = receiveVariable(t, updates)
+	require.Equal(t, "forced", variable.Value)
+	require.Equal(t, EvalReasonOverride, variable.Eval.Reason)
+
+	cancel()
+	require.Eventually(t, func() bool {
+		_, ok := <-updates
+		return !ok
+	}, 5*time.Second, time.Millisecond)
+}
+
+func TestClient_WatchVariable_RegistersBeforeEvaluating(t *testing.T) {
+	c, err := NewClient(test_environmentKey, &Options{
+		Offline:              true,
+		BootstrapConfig:      []byte(test_variable_types_config),
+		DisableEventDelivery: true,
+	})
+	require.NoError(t, err)
+	defer c.Close()
+
+	// The matcher runs during the first evaluation, when config updates must already reach the watcher
+	var watchers []int
+	require.NoError(t, c.SetOverride("string-var", "override", func(User) bool {
+		c.watchers.mutex.Lock()
+		defer c.watchers.mutex.Unlock()
+		watchers = append(watchers, len(c.watchers.watchers))
+		return false
+	}))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	updates, err := c.WatchVariable(ctx, User{UserId: "j_test"}, "string-var", "default")
+	require.NoError(t, err)
+	require.Equal(t, "on", receiveVariable(t, updates).Value)
+	require.Equal(t, []int{1}, watchers)
+}
+
+func TestClient_WatchVariable_NoDuplicateEvents(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+	httpCustomConfigMock(test_environmentKey, 200, test_variable_types_config)
+	var mutex sync.Mutex
+	var events []Event
+	httpmock.RegisterResponder("POST", "https://events.devcycle.com/v1/events/batch",
+		func(req *http.Request) (*http.Response, error) {
+			body, err := io.ReadAll(req.Body)
+			require.NoError(t, err)
+			var batch BatchEventsBody
+			require.NoError(t, json.Unmarshal(body, &batch))
+			mutex.Lock()
+			defer mutex.Unlock()
+			for _, record := range batch.Batch {
+				events = append(events, record.Events...)
+			}
+			return httpmock.NewStringResponse(201, `{}`), nil
+		})
+
+	c, err := NewClient(test_environmentKey, &Options{})
+	require.NoError(t, err)
+	defer c.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	updates, err := c.WatchVariable(ctx, User{UserId: "j_test"}, "string-var", "default")
+	require.NoError(t, err)
+	receiveVariable(t, updates)
+
+	changedConfig := strings.Replace(test_variable_types_config, `"value": "on"`, `"value": "changed"`, 1)
+	require.NoError(t, c.configManager.setConfig([]byte(changedConfig), "changed"))
+	receiveVariable(t, updates)
+
+	evaluations := func() float64 {
+		mutex.Lock()
+		defer mutex.Unlock()
+		var count float64
+		for _, event := range events {
+			if event.Type_ == api.EventType_AggVariableEvaluated && event.Target == "string-var" {
+				count += event.Value
+			}
+		}
+		return count
+	}
+	require.Eventually(t, func() bool {
+		require.NoError(t, c.FlushEvents())
+		return evaluations() > 0
+	}, 10*time.Second, 10*time.Millisecond)
+	require.Equal(t, float64(1), evaluations())
+}
+
+func TestClient_WatchVariable_CloudBucketing(t *testing.T) {
+	c, err := NewClient(test_environmentKey, &Options{EnableCloudBucketing: true})
+	require.NoError(t, err)
+
+	_, err = c.WatchVariable(context.Background(), User{UserId: "j_test"}, "string-var", "default")
+	require.Error(t, err)
+}
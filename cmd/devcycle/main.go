@@ -0,0 +1,80 @@
+// Command devcycle holds tools for working with DevCycle project configs offline.
+//
+// Usage:
+//
+//	devcycle lint <config.json>
+//
+// lint checks a config file the way the SDK loads it, and prints every problem found as JSON. Pass - to read the
+// config from stdin. It exits with 1 if the config has errors, and 2 if it can't be read.
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/BIwashi/go-server-sdk/v2/bucketing"
+	"github.com/BIwashi/go-server-sdk/v2/util"
+)
+
+const usage = "usage: devcycle lint <config.json>"
+
+// lintReport is printed by the lint command.
+type lintReport struct {
+	File     string                `json:"file"`
+	Errors   int                   `json:"errors"`
+	Warnings int                   `json:"warnings"`
+	Issues   []bucketing.LintIssue `json:"issues"`
+}
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr))
+}
+
+func run(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	if len(args) != 2 || args[0] != "lint" {
+		fmt.Fprintln(stderr, usage)
+		return 2
+	}
+	return lint(args[1], stdin, stdout, stderr)
+}
+
+func lint(path string, stdin io.Reader, stdout, stderr io.Writer) int {
+	var configJSON []byte
+	var err error
+	if path == "-" {
+		configJSON, err = io.ReadAll(stdin)
+	} else {
+		configJSON, err = os.ReadFile(path)
+	}
+	if err != nil {
+		fmt.Fprintf(stderr, "devcycle lint: %s\n", err)
+		return 2
+	}
+
+	// Every problem the SDK would log is reported as an issue
+	util.SetLogger(util.DiscardLogger{})
+	report := lintReport{File: path, Issues: bucketing.LintConfig(configJSON)}
+	if report.Issues == nil {
+		report.Issues = []bucketing.LintIssue{}
+	}
+	for _, issue := range report.Issues {
+		if issue.Severity == bucketing.LintError {
+			report.Errors++
+		} else {
+			report.Warnings++
+		}
+	}
+
+	encoder := json.NewEncoder(stdout)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(report); err != nil {
+		fmt.Fprintf(stderr, "devcycle lint: %s\n", err)
+		return 2
+	}
+	if report.Errors > 0 {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,36 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRun_Lint(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	code := run([]string{"lint", filepath.Join("..", "..", "testdata", "fixture_small_config.json")}, nil, &stdout, &stderr)
+	require.Equal(t, 0, code, stderr.String())
+	var report lintReport
+	require.NoError(t, json.Unmarshal(stdout.Bytes(), &report))
+	require.Zero(t, report.Errors)
+	require.Empty(t, report.Issues)
+
+	stdout.Reset()
+	code = run([]string{"lint", "-"}, strings.NewReader(`{"features": [`), &stdout, &stderr)
+	require.Equal(t, 1, code)
+	require.NoError(t, json.Unmarshal(stdout.Bytes(), &report))
+	require.Equal(t, 1, report.Errors)
+	require.Equal(t, "-", report.File)
+}
+
+func TestRun_Usage(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	require.Equal(t, 2, run(nil, nil, &stdout, &stderr))
+	require.Contains(t, stderr.String(), usage)
+	require.Equal(t, 2, run([]string{"lint", filepath.Join(t.TempDir(), "missing.json")}, nil, &stdout, &stderr))
+	require.Empty(t, stdout.String())
+}
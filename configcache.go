@@ -0,0 +1,119 @@
+package devcycle
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+var ErrConfigCacheChecksum = errors.New("cached config does not match its checksum")
+
+// cachedConfig is the on-disk format of the last known good config.
+type cachedConfig struct {
+	ETag     string          `json:"etag"`
+	Checksum string          `json:"checksum,omitempty"`
+	Config   json.RawMessage `json:"config"`
+}
+
+// configCache persists the last config fetched from the config CDN so that it can be served
+// on startup before the first fetch completes.
+type configCache struct {
+	path     string
+	checksum bool
+}
+
+func newConfigCache(sdkKey string, dir string, checksum bool) *configCache {
+	// Avoid writing the SDK key itself to the filesystem
+	keyHash := sha256.Sum256([]byte(sdkKey))
+	return &configCache{
+		path:     filepath.Join(dir, fmt.Sprintf("devcycle-config-%x.json", keyHash[:8])),
+		checksum: checksum,
+	}
+}
+
+func (c *configCache) load() (config []byte, eTag string, err error) {
+	data, err := os.ReadFile(c.path)
+	if err != nil {
+		return nil, "", err
+	}
+
+	var cached cachedConfig
+	err = json.Unmarshal(data, &cached)
+	if err != nil {
+		return nil, "", fmt.Errorf("invalid config cache file %s: %w", c.path, err)
+	}
+	if len(cached.Config) == 0 {
+		return nil, "", fmt.Errorf("config cache file %s has no config", c.path)
+	}
+	if c.checksum && cached.Checksum != configChecksum(cached.Config) {
+		return nil, "", fmt.Errorf("%w: %s", ErrConfigCacheChecksum, c.path)
+	}
+	return cached.Config, cached.ETag, nil
+}
+
+// store writes the config to a temporary file and renames it over the cache file, so that
+// readers never observe a partially written cache.
+func (c *configCache) store(config []byte, eTag string) (err error) {
+	// The config is stored compacted and without HTML escaping, as the encoder would rewrite it otherwise,
+	// so that the checksum covers exactly the bytes that load returns.
+	var compacted bytes.Buffer
+	err = json.Compact(&compacted, config)
+	if err != nil {
+		return err
+	}
+	cached := cachedConfig{ETag: eTag, Config: compacted.Bytes()}
+	if c.checksum {
+		cached.Checksum = configChecksum(cached.Config)
+	}
+	var data bytes.Buffer
+	encoder := json.NewEncoder(&data)
+	encoder.SetEscapeHTML(false)
+	err = encoder.Encode(cached)
+	if err != nil {
+		return err
+	}
+
+	return writeFileAtomically(c.path, data.Bytes())
+}
+
+// writeFileAtomically writes the data to a temporary file and renames it over path, creating the directory
+// if needed.
+func writeFileAtomically(path string, data []byte) (err error) {
+	dir := filepath.Dir(path)
+	err = os.MkdirAll(dir, 0o755)
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(dir, "."+filepath.Base(path)+"-*.tmp")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			_ = os.Remove(tmp.Name())
+		}
+	}()
+
+	_, err = tmp.Write(data)
+	if err == nil {
+		err = tmp.Sync()
+	}
+	closeErr := tmp.Close()
+	if err != nil {
+		return err
+	}
+	if closeErr != nil {
+		return closeErr
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+func configChecksum(config []byte) string {
+	sum := sha256.Sum256(config)
+	return hex.EncodeToString(sum[:])
+}
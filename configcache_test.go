@@ -0,0 +1,104 @@
+package devcycle
+
+import (
+	"net/http"
+	"os"
+	"testing"
+
+	"github.com/jarcoal/httpmock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfigCache_RoundTrip(t *testing.T) {
+	dir := t.TempDir()
+	cache := newConfigCache(test_environmentKey, dir, true)
+
+	_, _, err := cache.load()
+	require.ErrorIs(t, err, os.ErrNotExist)
+
+	err = cache.store([]byte(`{"name": "<tag> & more"}`), "etag-1")
+	require.NoError(t, err)
+
+	config, eTag, err := cache.load()
+	require.NoError(t, err)
+	require.Equal(t, "etag-1", eTag)
+	require.JSONEq(t, `{"name": "<tag> & more"}`, string(config))
+
+	err = cache.store([]byte(test_variable_types_config), "etag-2")
+	require.NoError(t, err)
+	_, eTag, err = cache.load()
+	require.NoError(t, err)
+	require.Equal(t, "etag-2", eTag)
+
+	// Temporary files are renamed over the cache file
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+}
+
+func TestConfigCache_ChecksumMismatch(t *testing.T) {
+	cache := newConfigCache(test_environmentKey, t.TempDir(), true)
+	require.NoError(t, cache.store([]byte(`{"value": 1}`), "etag"))
+
+	data, err := os.ReadFile(cache.path)
+	require.NoError(t, err)
+	tampered := []byte(string(data[:len(data)-4]) + "2}}\n")
+	require.NoError(t, os.WriteFile(cache.path, tampered, 0o600))
+
+	_, _, err = cache.load()
+	require.ErrorIs(t, err, ErrConfigCacheChecksum)
+
+	// Without checksums the tampered cache is accepted
+	cache.checksum = false
+	config, _, err := cache.load()
+	require.NoError(t, err)
+	require.JSONEq(t, `{"value": 2}`, string(config))
+}
+
+func TestEnvironmentConfigManager_ConfigCache(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+	httpConfigMock(200)
+
+	options := &Options{ConfigCacheDir: t.TempDir(), ConfigCacheChecksum: true}
+	options.CheckDefaults()
+
+	manager := NewEnvironmentConfigManager(test_environmentKey, &recordingConfigReceiver{}, options, NewConfiguration(options))
+	require.NoError(t, manager.initialFetch())
+
+	httpmock.RegisterResponder("GET", "https://config-cdn.devcycle.com/config/v1/server/"+test_environmentKey+".json",
+		func(req *http.Request) (*http.Response, error) {
+			require.Equal(t, "TESTING", req.Header.Get("If-None-Match"))
+			return httpmock.NewStringResponse(http.StatusNotModified, ""), nil
+		})
+
+	localBucketing := &recordingConfigReceiver{}
+	manager = NewEnvironmentConfigManager(test_environmentKey, localBucketing, options, NewConfiguration(options))
+	require.NoError(t, manager.loadCachedConfig())
+	require.True(t, manager.HasConfig())
+	require.Equal(t, "TESTING", manager.configETag)
+	require.Equal(t, 1, localBucketing.configureCount)
+
+	require.NoError(t, manager.initialFetch())
+	require.Equal(t, 1, localBucketing.configureCount)
+}
+
+func TestClient_ConfigCache_CDNUnavailable(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+	httpCustomConfigMock(test_environmentKey, 200, test_variable_types_config)
+
+	options := &Options{ConfigCacheDir: t.TempDir()}
+	c, err := NewClient(test_environmentKey, options)
+	require.NoError(t, err)
+	require.NoError(t, c.Close())
+
+	httpCustomConfigMock(test_environmentKey, 500, "")
+	c, err = NewClient(test_environmentKey, options)
+	require.NoError(t, err)
+	defer c.Close()
+
+	value, err := c.StringVariable(User{UserId: "j_test"}, "string-var", "off")
+	require.NoError(t, err)
+	require.Equal(t, "on", value)
+}
@@ -0,0 +1,187 @@
+package devcycle
+
+import (
+	"bytes"
+	"encoding/json"
+	"sort"
+)
+
+// ConfigChange describes a config that replaced the previous one. On the first config load OldETag is empty
+// and every entry of the config is reported as added.
+type ConfigChange struct {
+	OldETag string
+	NewETag string
+	// Features keyed by feature key
+	Features ConfigChangeSet
+	// Variables keyed by variable key
+	Variables ConfigChangeSet
+	// Targets keyed by "<feature key>/<target id>"
+	Targets ConfigChangeSet
+	// Audiences keyed by audience id, covering both shared audiences and the audiences of targets
+	Audiences ConfigChangeSet
+	// Distributions keyed by "<feature key>/<target id>" of the target they belong to
+	Distributions ConfigChangeSet
+	// Err is set when the configs could not be compared, in which case only the ETags are filled in
+	Err error
+}
+
+// HasChanges is false when the new config has the same features, variables and targeting as the old one.
+// It is true when the configs could not be compared.
+func (c ConfigChange) HasChanges() bool {
+	return c.Err != nil || !c.Features.empty() || !c.Variables.empty() || !c.Targets.empty() || !c.Audiences.empty() || !c.Distributions.empty()
+}
+
+// ConfigChangeSet lists the keys of the entries added, removed and modified by a config change, in sorted order.
+type ConfigChangeSet struct {
+	Added    []string
+	Removed  []string
+	Modified []string
+}
+
+func (s ConfigChangeSet) empty() bool {
+	return len(s.Added) == 0 && len(s.Removed) == 0 && len(s.Modified) == 0
+}
+
+type diffConfigBody struct {
+	Audiences map[string]json.RawMessage `json:"audiences"`
+	Features  []json.RawMessage          `json:"features"`
+	Variables []json.RawMessage          `json:"variables"`
+}
+
+type diffKeyed struct {
+	Id  string `json:"_id"`
+	Key string `json:"key"`
+}
+
+type diffFeature struct {
+	Key           string `json:"key"`
+	Configuration struct {
+		Targets []json.RawMessage `json:"targets"`
+	} `json:"configuration"`
+}
+
+type diffTarget struct {
+	Id           string          `json:"_id"`
+	Audience     json.RawMessage `json:"_audience"`
+	Distribution json.RawMessage `json:"distribution"`
+}
+
+// configEntries holds the compacted JSON of every entry of a config that ConfigChange reports on.
+type configEntries struct {
+	features      map[string]string
+	variables     map[string]string
+	targets       map[string]string
+	audiences     map[string]string
+	distributions map[string]string
+}
+
+func newConfigChange(oldConfig []byte, oldETag string, newConfig []byte, newETag string) (ConfigChange, error) {
+	change := ConfigChange{OldETag: oldETag, NewETag: newETag}
+	oldEntries, err := parseConfigEntries(oldConfig)
+	if err != nil {
+		return change, err
+	}
+	newEntries, err := parseConfigEntries(newConfig)
+	if err != nil {
+		return change, err
+	}
+
+	change.Features = diffConfigEntries(oldEntries.features, newEntries.features)
+	change.Variables = diffConfigEntries(oldEntries.variables, newEntries.variables)
+	change.Targets = diffConfigEntries(oldEntries.targets, newEntries.targets)
+	change.Audiences = diffConfigEntries(oldEntries.audiences, newEntries.audiences)
+	change.Distributions = diffConfigEntries(oldEntries.distributions, newEntries.distributions)
+	return change, nil
+}
+
+func parseConfigEntries(config []byte) (configEntries, error) {
+	entries := configEntries{
+		features:      make(map[string]string),
+		variables:     make(map[string]string),
+		targets:       make(map[string]string),
+		audiences:     make(map[string]string),
+		distributions: make(map[string]string),
+	}
+	if len(config) == 0 {
+		return entries, nil
+	}
+
+	var body diffConfigBody
+	err := json.Unmarshal(config, &body)
+	if err != nil {
+		return entries, err
+	}
+
+	for id, audience := range body.Audiences {
+		entries.audiences[id] = compactJSON(audience)
+	}
+	for _, rawVariable := range body.Variables {
+		var variable diffKeyed
+		err = json.Unmarshal(rawVariable, &variable)
+		if err != nil {
+			return entries, err
+		}
+		entries.variables[variable.Key] = compactJSON(rawVariable)
+	}
+	for _, rawFeature := range body.Features {
+		var feature diffFeature
+		err = json.Unmarshal(rawFeature, &feature)
+		if err != nil {
+			return entries, err
+		}
+		entries.features[feature.Key] = compactJSON(rawFeature)
+
+		for _, rawTarget := range feature.Configuration.Targets {
+			var target diffTarget
+			err = json.Unmarshal(rawTarget, &target)
+			if err != nil {
+				return entries, err
+			}
+			targetKey := feature.Key + "/" + target.Id
+			entries.targets[targetKey] = compactJSON(rawTarget)
+			entries.distributions[targetKey] = compactJSON(target.Distribution)
+
+			if len(target.Audience) > 0 {
+				var audience diffKeyed
+				err = json.Unmarshal(target.Audience, &audience)
+				if err != nil {
+					return entries, err
+				}
+				if audience.Id != "" {
+					entries.audiences[audience.Id] = compactJSON(target.Audience)
+				}
+			}
+		}
+	}
+	return entries, nil
+}
+
+func diffConfigEntries(oldEntries, newEntries map[string]string) ConfigChangeSet {
+	var changes ConfigChangeSet
+	for key, newEntry := range newEntries {
+		oldEntry, ok := oldEntries[key]
+		if !ok {
+			changes.Added = append(changes.Added, key)
+		} else if oldEntry != newEntry {
+			changes.Modified = append(changes.Modified, key)
+		}
+	}
+	for key := range oldEntries {
+		if _, ok := newEntries[key]; !ok {
+			changes.Removed = append(changes.Removed, key)
+		}
+	}
+	sort.Strings(changes.Added)
+	sort.Strings(changes.Removed)
+	sort.Strings(changes.Modified)
+	return changes
+}
+
+// compactJSON removes insignificant whitespace so that formatting differences are not reported as changes.
+func compactJSON(raw json.RawMessage) string {
+	var compacted bytes.Buffer
+	if err := json.Compact(&compacted, raw); err != nil {
+		return string(raw)
+	}
+	return compacted.String()
+}
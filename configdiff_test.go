@@ -0,0 +1,128 @@
+package devcycle
+
+import (
+	"testing"
+
+	"github.com/jarcoal/httpmock"
+	"github.com/stretchr/testify/require"
+)
+
+const diffOldConfig = `{
+	"audiences": {"aud-shared": {"filters": {"operator": "and", "filters": []}}},
+	"features": [
+		{"_id": "f1", "key": "feature-one", "configuration": {"targets": [
+			{"_id": "t1", "_audience": {"_id": "aud-1", "filters": {"operator": "and", "filters": [{"type": "all"}]}},
+			 "distribution": [{"_variation": "v1", "percentage": 1}]},
+			{"_id": "t2", "_audience": {"_id": "aud-2", "filters": {"operator": "and", "filters": []}},
+			 "distribution": [{"_variation": "v1", "percentage": 1}]}
+		]}},
+		{"_id": "f2", "key": "feature-two", "configuration": {"targets": []}}
+	],
+	"variables": [
+		{"_id": "var1", "key": "var-one", "type": "Boolean"},
+		{"_id": "var2", "key": "var-two", "type": "String"}
+	]
+}`
+
+const diffNewConfig = `{
+	"audiences": {"aud-shared": {"filters": {"operator": "or", "filters": []}}},
+	"features": [
+		{"_id": "f1", "key": "feature-one", "configuration": {"targets": [
+			{"_id": "t1", "_audience": {"_id": "aud-1", "filters": {"operator": "and", "filters": [{"type": "all"}]}},
+			 "distribution": [{"_variation": "v1", "percentage": 0.5}, {"_variation": "v2", "percentage": 0.5}]},
+			{"_id": "t3", "_audience": {"_id": "aud-3", "filters": {"operator": "and", "filters": []}},
+			 "distribution": [{"_variation": "v1", "percentage": 1}]}
+		]}},
+		{"_id": "f3", "key": "feature-three", "configuration": {"targets": []}}
+	],
+	"variables": [
+		{"_id": "var1", "key": "var-one", "type": "Boolean"},
+		{"_id": "var3", "key": "var-three", "type": "Number"}
+	]
+}`
+
+func TestNewConfigChange(t *testing.T) {
+	change, err := newConfigChange([]byte(diffOldConfig), "old", []byte(diffNewConfig), "new")
+	require.NoError(t, err)
+	require.True(t, change.HasChanges())
+	require.Equal(t, "old", change.OldETag)
+	require.Equal(t, "new", change.NewETag)
+
+	require.Equal(t, ConfigChangeSet{
+		Added:    []string{"feature-three"},
+		Removed:  []string{"feature-two"},
+		Modified: []string{"feature-one"},
+	}, change.Features)
+	require.Equal(t, ConfigChangeSet{
+		Added:   []string{"var-three"},
+		Removed: []string{"var-two"},
+	}, change.Variables)
+	require.Equal(t, ConfigChangeSet{
+		Added:    []string{"feature-one/t3"},
+		Removed:  []string{"feature-one/t2"},
+		Modified: []string{"feature-one/t1"},
+	}, change.Targets)
+	require.Equal(t, ConfigChangeSet{
+		Added:    []string{"aud-3"},
+		Removed:  []string{"aud-2"},
+		Modified: []string{"aud-shared"},
+	}, change.Audiences)
+	require.Equal(t, ConfigChangeSet{
+		Added:    []string{"feature-one/t3"},
+		Removed:  []string{"feature-one/t2"},
+		Modified: []string{"feature-one/t1"},
+	}, change.Distributions)
+}
+
+func TestNewConfigChange_FirstLoadAndFormatting(t *testing.T) {
+	change, err := newConfigChange(nil, "", []byte(diffOldConfig), "etag")
+	require.NoError(t, err)
+	require.Equal(t, []string{"feature-one", "feature-two"}, change.Features.Added)
+	require.Equal(t, []string{"var-one", "var-two"}, change.Variables.Added)
+	require.Empty(t, change.Features.Removed)
+
+	// Reformatting the same config is not a change
+	compacted := compactJSON([]byte(diffOldConfig))
+	change, err = newConfigChange([]byte(diffOldConfig), "etag", []byte(compacted), "etag-2")
+	require.NoError(t, err)
+	require.False(t, change.HasChanges())
+}
+
+func TestEnvironmentConfigManager_OnConfigUpdated(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+	httpCustomConfigMock(test_environmentKey, 200, test_variable_types_config)
+
+	var changes []ConfigChange
+	options := &Options{OnConfigUpdated: func(change ConfigChange) {
+		changes = append(changes, change)
+	}}
+	options.CheckDefaults()
+
+	manager := NewEnvironmentConfigManager(test_environmentKey, &recordingConfigReceiver{}, options, NewConfiguration(options))
+	require.NoError(t, manager.bootstrap([]byte(test_variable_types_config)))
+	require.NoError(t, manager.initialFetch())
+
+	require.Len(t, changes, 2)
+	require.Equal(t, "", changes[0].OldETag)
+	require.Equal(t, []string{"variable-types"}, changes[0].Features.Added)
+	require.Equal(t, "", changes[1].OldETag)
+	require.Equal(t, "TESTING", changes[1].NewETag)
+	require.False(t, changes[1].HasChanges())
+}
+
+func TestEnvironmentConfigManager_OnConfigUpdatedComparisonError(t *testing.T) {
+	var changes []ConfigChange
+	options := &Options{OnConfigUpdated: func(change ConfigChange) {
+		changes = append(changes, change)
+	}}
+	options.CheckDefaults()
+	manager := NewEnvironmentConfigManager(test_environmentKey, &recordingConfigReceiver{}, options, NewConfiguration(options))
+
+	manager.notifyConfigUpdate([]byte("{"), "etag", []byte(test_variable_types_config), "etag-2")
+	require.Len(t, changes, 1)
+	require.Error(t, changes[0].Err)
+	require.Equal(t, "etag", changes[0].OldETag)
+	require.Equal(t, "etag-2", changes[0].NewETag)
+	require.True(t, changes[0].HasChanges())
+}
@@ -4,9 +4,12 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	"os"
+	"sync"
 	"sync/atomic"
 	"time"
 
@@ -31,6 +34,17 @@ type EnvironmentConfigManager struct {
 	cfg            *HTTPConfiguration
 	hasConfig      atomic.Bool
 	ticker         *time.Ticker
+	cache          *configCache
+	onConfigUpdate func(ConfigChange)
+	onConfigStored func()
+	// fetchMutex serializes config updates from polling and streaming
+	fetchMutex            sync.Mutex
+	pollingInterval       time.Duration
+	streamURL             string
+	streamClient          *http.Client
+	streamConnected       atomic.Bool
+	streamPollingInterval time.Duration
+	streamRetryBase       time.Duration
 }
 
 func NewEnvironmentConfigManager(
@@ -48,8 +62,21 @@ func NewEnvironmentConfigManager(
 			// Use the configurable timeout because fetching the first config can block SDK initialization.
 			Timeout: options.RequestTimeout,
 		},
-		hasConfig: atomic.Bool{},
-		firstLoad: true,
+		hasConfig:      atomic.Bool{},
+		firstLoad:      true,
+		onConfigUpdate: options.OnConfigUpdated,
+	}
+
+	if options.ConfigStreamURI != "" {
+		configManager.streamURL = options.ConfigStreamURI
+		// The stream is long-lived, so it must not be bound by the request timeout
+		configManager.streamClient = &http.Client{}
+		configManager.streamPollingInterval = options.ConfigStreamPollingIntervalMS
+		configManager.streamRetryBase = time.Second
+	}
+
+	if options.ConfigCacheDir != "" {
+		configManager.cache = newConfigCache(sdkKey, options.ConfigCacheDir, options.ConfigCacheChecksum)
 	}
 
 	configManager.context, configManager.stopPolling = context.WithCancel(context.Background())
@@ -60,6 +87,7 @@ func NewEnvironmentConfigManager(
 func (e *EnvironmentConfigManager) StartPolling(
 	interval time.Duration,
 ) {
+	e.pollingInterval = interval
 	e.ticker = time.NewTicker(interval)
 
 	go func() {
@@ -70,16 +98,23 @@ func (e *EnvironmentConfigManager) StartPolling(
 				e.ticker.Stop()
 				return
 			case <-e.ticker.C:
-				err := e.fetchConfig(CONFIG_RETRIES)
-				if err != nil {
-					util.Warnf("Error fetching config: %s\n", err)
-				}
+				e.refreshConfigAndLog()
 			}
 		}
 	}()
+
+	if e.streamURL != "" {
+		e.startStreaming()
+	}
 }
 
 func (e *EnvironmentConfigManager) initialFetch() error {
+	return e.refreshConfig()
+}
+
+func (e *EnvironmentConfigManager) refreshConfig() error {
+	e.fetchMutex.Lock()
+	defer e.fetchMutex.Unlock()
 	return e.fetchConfig(CONFIG_RETRIES)
 }
 
@@ -156,15 +191,29 @@ func (e *EnvironmentConfigManager) setConfigFromResponse(response *http.Response
 		return fmt.Errorf("invalid JSON data received for config")
 	}
 
-	e.configETag = response.Header.Get("Etag")
-
-	err = e.setConfig(config, e.configETag)
+	err = e.storeReceivedConfig(config, response.Header.Get("Etag"))
+	if err != nil {
+		return err
+	}
+	util.Infof("Config set. ETag: %s\n", e.configETag)
+	return nil
+}
 
+// storeReceivedConfig stores a config received from DevCycle, by the config CDN or the config stream, and writes
+// it to the config cache so that it is served after a restart.
+func (e *EnvironmentConfigManager) storeReceivedConfig(config []byte, eTag string) error {
+	err := e.setConfig(config, eTag)
 	if err != nil {
 		return err
 	}
 
-	util.Infof("Config set. ETag: %s\n", e.configETag)
+	if e.cache != nil {
+		err = e.cache.store(config, e.configETag)
+		if err != nil {
+			util.Warnf("Failed to write config cache: %s", err)
+		}
+	}
+
 	if e.firstLoad {
 		e.firstLoad = false
 		util.Infof("DevCycle SDK Initialized.")
@@ -172,17 +221,75 @@ func (e *EnvironmentConfigManager) setConfigFromResponse(response *http.Response
 	return nil
 }
 
+// bootstrap stores a config that was supplied locally rather than fetched from the config CDN.
+// It has no ETag, so the first fetch after bootstrapping always downloads the full config.
+func (e *EnvironmentConfigManager) bootstrap(config []byte) error {
+	if !json.Valid(config) {
+		return fmt.Errorf("invalid JSON data provided for bootstrap config")
+	}
+	err := e.setConfig(config, "")
+	if err != nil {
+		return err
+	}
+	util.Infof("Bootstrap config set.")
+	return nil
+}
+
+// loadCachedConfig serves the last config written to the config cache, if any, and sends its ETag
+// with the next fetch so that an unchanged config is not downloaded again.
+func (e *EnvironmentConfigManager) loadCachedConfig() error {
+	if e.cache == nil {
+		return nil
+	}
+	config, eTag, err := e.cache.load()
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	err = e.setConfig(config, eTag)
+	if err != nil {
+		return err
+	}
+	util.Infof("Cached config set. ETag: %s\n", eTag)
+	return nil
+}
+
 func (e *EnvironmentConfigManager) setConfig(config []byte, eTag string) error {
 	err := e.localBucketing.StoreConfig(config, eTag)
 	if err != nil {
 		return err
 	}
+	oldConfig, oldETag := e.rawConfig, e.configETag
 	e.rawConfig = config
+	e.configETag = eTag
 	e.hasConfig.Store(true)
 
+	if e.onConfigUpdate != nil {
+		e.notifyConfigUpdate(oldConfig, oldETag, config, eTag)
+	}
+	if e.onConfigStored != nil {
+		e.onConfigStored()
+	}
 	return nil
 }
 
+func (e *EnvironmentConfigManager) notifyConfigUpdate(oldConfig []byte, oldETag string, newConfig []byte, newETag string) {
+	defer func() {
+		if r := recover(); r != nil {
+			util.Errorf("recovered from panic in OnConfigUpdated: %v", r)
+		}
+	}()
+
+	change, err := newConfigChange(oldConfig, oldETag, newConfig, newETag)
+	if err != nil {
+		util.Warnf("Failed to compute config change: %s", err)
+		change.Err = err
+	}
+	e.onConfigUpdate(change)
+}
+
 func (e *EnvironmentConfigManager) getConfigURL() string {
 	configBasePath := e.cfg.ConfigCDNBasePath
 
@@ -0,0 +1,197 @@
+package devcycle
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/BIwashi/go-server-sdk/v2/util"
+)
+
+const (
+	// CONFIG_UPDATED_EVENT is the Server-Sent Events event type announcing a new config.
+	CONFIG_UPDATED_EVENT = "configUpdated"
+
+	maxStreamMessageSize = 10 * 1024 * 1024
+	maxStreamRetryDelay  = time.Minute
+)
+
+// configUpdatedMessage is the data of a configUpdated event. When Config is set it is applied directly,
+// otherwise the config is fetched from the config CDN unless ETag matches the current config.
+type configUpdatedMessage struct {
+	ETag   string          `json:"etag"`
+	Config json.RawMessage `json:"config"`
+}
+
+type streamEvent struct {
+	eventType string
+	data      string
+}
+
+// startStreaming holds a connection to the config stream until the config manager is closed,
+// reconnecting with exponential backoff whenever the connection fails.
+func (e *EnvironmentConfigManager) startStreaming() {
+	go func() {
+		attempt := 0
+		connectedBefore := false
+		for {
+			connected, err := e.streamConfigUpdates(connectedBefore)
+			e.setStreamConnected(false)
+			if e.context.Err() != nil {
+				util.Infof("Stopping config streaming.")
+				return
+			}
+			if connected {
+				connectedBefore = true
+				attempt = 0
+			}
+			util.Warnf("Config stream disconnected, reconnecting: %v", err)
+
+			timer := time.NewTimer(e.streamRetryDelay(attempt))
+			select {
+			case <-e.context.Done():
+				timer.Stop()
+				return
+			case <-timer.C:
+			}
+			attempt++
+		}
+	}()
+}
+
+func (e *EnvironmentConfigManager) streamRetryDelay(attempt int) time.Duration {
+	delay := time.Duration(float64(e.streamRetryBase) * math.Pow(2, float64(attempt)))
+	if delay <= 0 || delay > maxStreamRetryDelay {
+		return maxStreamRetryDelay
+	}
+	return delay
+}
+
+// streamConfigUpdates connects to the config stream and handles its events until the connection ends.
+// It reports whether the connection was established.
+func (e *EnvironmentConfigManager) streamConfigUpdates(reconnecting bool) (connected bool, err error) {
+	req, err := http.NewRequestWithContext(e.context, "GET", e.streamURL, nil)
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Accept", "text/event-stream")
+	req.Header.Set("Cache-Control", "no-cache")
+	req.Header.Set("Authorization", e.sdkKey)
+
+	resp, err := e.streamClient.Do(req)
+	if err != nil {
+		return false, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return false, fmt.Errorf("unexpected config stream response code: %d", resp.StatusCode)
+	}
+
+	util.Infof("Config stream connected.")
+	e.setStreamConnected(true)
+	if reconnecting {
+		// Catch up on updates announced while the stream was disconnected
+		e.refreshConfigAndLog()
+	}
+
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 64*1024), maxStreamMessageSize)
+	var event streamEvent
+	var data []string
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case line == "":
+			if len(data) > 0 || event.eventType != "" {
+				event.data = strings.Join(data, "\n")
+				e.handleStreamEvent(event)
+			}
+			event = streamEvent{}
+			data = nil
+		case strings.HasPrefix(line, ":"):
+			// Comment, used by servers to keep the connection alive
+		default:
+			field, value, _ := strings.Cut(line, ":")
+			value = strings.TrimPrefix(value, " ")
+			switch field {
+			case "event":
+				event.eventType = value
+			case "data":
+				data = append(data, value)
+			}
+		}
+	}
+	err = scanner.Err()
+	if err == nil {
+		err = fmt.Errorf("config stream closed by server")
+	}
+	return true, err
+}
+
+func (e *EnvironmentConfigManager) handleStreamEvent(event streamEvent) {
+	if event.eventType != CONFIG_UPDATED_EVENT {
+		return
+	}
+
+	var message configUpdatedMessage
+	if strings.TrimSpace(event.data) != "" {
+		err := json.Unmarshal([]byte(event.data), &message)
+		if err != nil {
+			util.Warnf("Invalid config stream message, fetching config: %s", err)
+			e.refreshConfigAndLog()
+			return
+		}
+	}
+
+	if message.ETag != "" && message.ETag == e.currentETag() {
+		return
+	}
+
+	if len(message.Config) > 0 && string(message.Config) != "null" {
+		e.fetchMutex.Lock()
+		defer e.fetchMutex.Unlock()
+		err := e.storeReceivedConfig(message.Config, message.ETag)
+		if err != nil {
+			util.Warnf("Error setting config from stream: %s", err)
+			return
+		}
+		util.Infof("Config set from stream. ETag: %s\n", message.ETag)
+		return
+	}
+	e.refreshConfigAndLog()
+}
+
+func (e *EnvironmentConfigManager) refreshConfigAndLog() {
+	err := e.refreshConfig()
+	if err != nil {
+		util.Warnf("Error fetching config: %s\n", err)
+	}
+}
+
+// setStreamConnected switches polling to the slow interval while the stream is connected,
+// and back to the regular interval when it is not.
+func (e *EnvironmentConfigManager) setStreamConnected(connected bool) {
+	if e.streamConnected.Swap(connected) == connected {
+		return
+	}
+	if connected {
+		e.ticker.Reset(e.streamPollingInterval)
+	} else {
+		e.ticker.Reset(e.pollingInterval)
+	}
+}
+
+// StreamConnected reports whether the config stream is currently connected.
+func (e *EnvironmentConfigManager) StreamConnected() bool {
+	return e.streamConnected.Load()
+}
+
+func (e *EnvironmentConfigManager) currentETag() string {
+	e.fetchMutex.Lock()
+	defer e.fetchMutex.Unlock()
+	return e.configETag
+}
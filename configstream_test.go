@@ -0,0 +1,157 @@
+package devcycle
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// testStreamServer serves test_config on the config CDN endpoint and forwards the messages
+// sent on its channel to the connected config stream.
+type testStreamServer struct {
+	*httptest.Server
+	mutex          sync.Mutex
+	eTag           string
+	configRequests atomic.Int32
+	connections    atomic.Int32
+	messages       chan string
+	dropStream     chan struct{}
+}
+
+func newTestStreamServer() *testStreamServer {
+	s := &testStreamServer{eTag: "v1", messages: make(chan string, 10), dropStream: make(chan struct{}, 1)}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/config/v1/server/"+test_environmentKey+".json", func(w http.ResponseWriter, r *http.Request) {
+		s.configRequests.Add(1)
+		s.mutex.Lock()
+		eTag := s.eTag
+		s.mutex.Unlock()
+		w.Header().Set("ETag", eTag)
+		if r.Header.Get("If-None-Match") == eTag {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		_, _ = w.Write([]byte(test_config))
+	})
+	mux.HandleFunc("/stream", func(w http.ResponseWriter, r *http.Request) {
+		s.connections.Add(1)
+		w.Header().Set("Content-Type", "text/event-stream")
+		_, _ = w.Write([]byte(": connected\n\n"))
+		w.(http.Flusher).Flush()
+		for {
+			select {
+			case message := <-s.messages:
+				_, _ = w.Write([]byte(message))
+				w.(http.Flusher).Flush()
+			case <-s.dropStream:
+				return
+			case <-r.Context().Done():
+				return
+			}
+		}
+	})
+	s.Server = httptest.NewServer(mux)
+	return s
+}
+
+func (s *testStreamServer) setETag(eTag string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.eTag = eTag
+}
+
+func newStreamingConfigManager(t *testing.T, server *testStreamServer, options *Options) *EnvironmentConfigManager {
+	options.ConfigCDNURI = server.URL
+	options.ConfigStreamURI = server.URL + "/stream"
+	options.CheckDefaults()
+	manager := NewEnvironmentConfigManager(test_environmentKey, &recordingConfigReceiver{}, options, NewConfiguration(options))
+	manager.streamRetryBase = 10 * time.Millisecond
+	require.NoError(t, manager.initialFetch())
+	manager.StartPolling(options.ConfigPollingIntervalMS)
+	require.Eventually(t, manager.StreamConnected, 5*time.Second, time.Millisecond)
+	return manager
+}
+
+func TestEnvironmentConfigManager_Streaming(t *testing.T) {
+	server := newTestStreamServer()
+	defer server.Close()
+	options := &Options{ConfigCacheDir: t.TempDir()}
+	manager := newStreamingConfigManager(t, server, options)
+	defer manager.Close()
+	require.Equal(t, int32(1), server.configRequests.Load())
+
+	// The current ETag is ignored, an inline config is applied without a fetch
+	server.messages <- "event: configUpdated\ndata: {\"etag\": \"v1\"}\n\n"
+	server.messages <- fmt.Sprintf("event: configUpdated\ndata: {\"etag\": \"inline\", \"config\": %s}\n\n", test_config)
+	require.Eventually(t, func() bool { return manager.currentETag() == "inline" }, 5*time.Second, time.Millisecond)
+	require.Equal(t, int32(1), server.configRequests.Load())
+
+	// Inline configs are cached like fetched ones
+	_, cachedETag, err := newConfigCache(test_environmentKey, options.ConfigCacheDir, false).load()
+	require.NoError(t, err)
+	require.Equal(t, "inline", cachedETag)
+
+	// A new ETag triggers a conditional fetch, as does an update without data
+	server.setETag("v2")
+	server.messages <- ": keep-alive\n\nevent: configUpdated\ndata: {\"etag\":\ndata: \"v2\"}\n\n"
+	require.Eventually(t, func() bool { return manager.currentETag() == "v2" }, 5*time.Second, time.Millisecond)
+	require.Equal(t, int32(2), server.configRequests.Load())
+
+	server.messages <- "event: configUpdated\n\n"
+	require.Eventually(t, func() bool { return server.configRequests.Load() == 3 }, 5*time.Second, time.Millisecond)
+}
+
+func TestEnvironmentConfigManager_Streaming_Reconnects(t *testing.T) {
+	server := newTestStreamServer()
+	defer server.Close()
+	manager := newStreamingConfigManager(t, server, &Options{})
+	defer manager.Close()
+
+	server.dropStream <- struct{}{}
+	require.Eventually(t, func() bool {
+		return server.connections.Load() == 2 && manager.StreamConnected()
+	}, 5*time.Second, time.Millisecond)
+
+	// Updates missed while disconnected are fetched after reconnecting
+	require.Eventually(t, func() bool { return server.configRequests.Load() == 2 }, 5*time.Second, time.Millisecond)
+}
+
+func TestEnvironmentConfigManager_StreamPollingInterval(t *testing.T) {
+	server := newTestStreamServer()
+	defer server.Close()
+	options := &Options{ConfigCDNURI: server.URL, ConfigStreamURI: server.URL + "/stream"}
+	options.CheckDefaults()
+	manager := NewEnvironmentConfigManager(test_environmentKey, &recordingConfigReceiver{}, options, NewConfiguration(options))
+	manager.streamPollingInterval = time.Hour
+	// The stream is not reconnected once dropped
+	manager.streamRetryBase = time.Hour
+	require.NoError(t, manager.initialFetch())
+	manager.StartPolling(10 * time.Millisecond)
+	defer manager.Close()
+
+	// Polling slows down while the stream is connected
+	require.Eventually(t, manager.StreamConnected, 5*time.Second, time.Millisecond)
+	time.Sleep(50 * time.Millisecond)
+	requests := server.configRequests.Load()
+	time.Sleep(200 * time.Millisecond)
+	require.Equal(t, requests, server.configRequests.Load())
+
+	// and resumes at the regular interval when it drops
+	server.dropStream <- struct{}{}
+	require.Eventually(t, func() bool { return !manager.StreamConnected() }, 5*time.Second, time.Millisecond)
+	require.Eventually(t, func() bool { return server.configRequests.Load() >= requests+3 }, 5*time.Second, time.Millisecond)
+}
+
+func TestEnvironmentConfigManager_StreamRetryDelay(t *testing.T) {
+	manager := &EnvironmentConfigManager{streamRetryBase: time.Second}
+	require.Equal(t, time.Second, manager.streamRetryDelay(0))
+	require.Equal(t, 8*time.Second, manager.streamRetryDelay(3))
+	require.Equal(t, maxStreamRetryDelay, manager.streamRetryDelay(10))
+	require.Equal(t, maxStreamRetryDelay, manager.streamRetryDelay(1000))
+}
@@ -1,7 +1,9 @@
 package devcycle
 
 import (
+	"errors"
 	"net/http"
+	"os"
 	"time"
 
 	"github.com/BIwashi/go-server-sdk/v2/api"
@@ -65,6 +67,46 @@ type Options struct {
 	OnInitializedChannel         chan bool
 	BucketingAPIURI              string
 	Logger                       util.Logger
+	// BootstrapConfig is a raw project config loaded before the first fetch from the config CDN.
+	BootstrapConfig []byte
+	// BootstrapConfigPath is the path of a project config file loaded before the first fetch from the config CDN.
+	BootstrapConfigPath string
+	// Offline serves the bootstrap config only and never contacts the config CDN.
+	Offline bool
+	// ConfigCacheDir is a directory where the last config fetched from the config CDN is stored and loaded from on startup.
+	ConfigCacheDir string
+	// ConfigCacheChecksum stores a checksum with the cached config and ignores a cache that does not match it.
+	ConfigCacheChecksum bool
+	// ConfigStreamURI is a Server-Sent Events endpoint announcing config updates with "configUpdated" events.
+	// When set, the config is updated as soon as an update is announced, and polled every
+	// ConfigStreamPollingIntervalMS instead of ConfigPollingIntervalMS while the stream is connected.
+	ConfigStreamURI               string
+	ConfigStreamPollingIntervalMS time.Duration
+	// OnConfigUpdated is called with the changes between the old and new config after each new config is stored.
+	// It runs synchronously while the next config fetch waits for it, so it must not block; hand slow work off
+	// to another goroutine.
+	OnConfigUpdated func(ConfigChange)
+	// DisableEventDelivery drops flushed events instead of sending them to the events API.
+	DisableEventDelivery bool
+	// GeoIPDatabasePath is a MaxMind DB (.mmdb) file used to set the country of users that have an IP but no
+	// country. The file is reloaded when it changes.
+	GeoIPDatabasePath string
+	// GeoIPCustomData also adds the "region" and "city" of the user's IP to their custom data, unless already set.
+	GeoIPCustomData bool
+	// OptInStore records the features users opted in to with Client.OptIn, which the opt-in audiences of projects
+	// with opt-in enabled target. Defaults to an in-memory store.
+	OptInStore OptInStore
+	// UserDataStore keeps the custom data of users between evaluations when EnableEdgeDB is set with local bucketing,
+	// so audiences of projects with EdgeDB enabled can target data sent in earlier evaluations. Defaults to an
+	// in-memory store when EnableEdgeDB is set.
+	UserDataStore UserDataStore
+	// BucketingKey names the user field, like "email", or else the custom data key that buckets users into rollouts
+	// and distributions with local bucketing, so that all users sharing a value get the same variation. Targets can
+	// name their own key. Users without a value for the key are bucketed by their UserId.
+	BucketingKey string
+	// Clock tells the time that local bucketing evaluates scheduled and gradual rollouts at, and that events are
+	// timestamped with. Defaults to the system time.
+	Clock Clock
 	AdvancedOptions
 }
 
@@ -80,6 +122,22 @@ func (o *Options) eventQueueOptions() *EventQueueOptions {
 	}
 }
 
+var ErrOfflineWithoutBootstrapConfig = errors.New("offline mode requires BootstrapConfig or BootstrapConfigPath to be set")
+
+// loadBootstrapConfig returns the configured bootstrap config, or nil if none is set.
+func (o *Options) loadBootstrapConfig() ([]byte, error) {
+	if len(o.BootstrapConfig) > 0 && o.BootstrapConfigPath != "" {
+		return nil, errors.New("only one of BootstrapConfig and BootstrapConfigPath can be set")
+	}
+	if len(o.BootstrapConfig) > 0 {
+		return o.BootstrapConfig, nil
+	}
+	if o.BootstrapConfigPath != "" {
+		return os.ReadFile(o.BootstrapConfigPath)
+	}
+	return nil, nil
+}
+
 func (o *Options) CheckDefaults() {
 	if o.ConfigCDNURI == "" {
 		o.ConfigCDNURI = "https://config-cdn.devcycle.com"
@@ -95,10 +153,22 @@ func (o *Options) CheckDefaults() {
 		util.Warnf("EventFlushIntervalMS cannot be less than 500ms or longer than 1 minute. Defaulting to 30 seconds.")
 		o.EventFlushIntervalMS = time.Second * 30
 	}
+	if o.OptInStore == nil {
+		o.OptInStore = NewInMemoryOptInStore()
+	}
+	if o.EnableEdgeDB && o.UserDataStore == nil {
+		o.UserDataStore = NewInMemoryUserDataStore()
+	}
 	if o.ConfigPollingIntervalMS < time.Second*1 {
 		util.Warnf("ConfigPollingIntervalMS cannot be less than 1 second. Defaulting to 10 seconds.")
 		o.ConfigPollingIntervalMS = time.Second * 10
 	}
+	if o.ConfigStreamPollingIntervalMS == 0 {
+		o.ConfigStreamPollingIntervalMS = time.Minute * 5
+	} else if o.ConfigStreamPollingIntervalMS < o.ConfigPollingIntervalMS {
+		util.Warnf("ConfigStreamPollingIntervalMS cannot be less than ConfigPollingIntervalMS. Defaulting to ConfigPollingIntervalMS.")
+		o.ConfigStreamPollingIntervalMS = o.ConfigPollingIntervalMS
+	}
 	if o.RequestTimeout <= time.Second*5 {
 		o.RequestTimeout = time.Second * 5
 	}
@@ -0,0 +1,140 @@
+package devcycletest
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+)
+
+// ConfigBuilder builds a minimal project config in which every feature serves its variables to all users.
+type ConfigBuilder struct {
+	features []builtFeature
+	nextId   int
+}
+
+type builtFeature struct {
+	key       string
+	variables []builtVariable
+}
+
+type builtVariable struct {
+	key   string
+	value interface{}
+}
+
+func NewConfigBuilder() *ConfigBuilder {
+	return &ConfigBuilder{}
+}
+
+// Variable adds a feature with the same key as the variable, serving value to all users.
+func (b *ConfigBuilder) Variable(key string, value interface{}) *ConfigBuilder {
+	return b.Feature(key, map[string]interface{}{key: value})
+}
+
+// Feature adds a feature serving the variables, keyed by variable key, to all users.
+func (b *ConfigBuilder) Feature(key string, variables map[string]interface{}) *ConfigBuilder {
+	// Sort the variables so that the built config is the same on every call
+	variableKeys := make([]string, 0, len(variables))
+	for variableKey := range variables {
+		variableKeys = append(variableKeys, variableKey)
+	}
+	sort.Strings(variableKeys)
+
+	feature := builtFeature{key: key}
+	for _, variableKey := range variableKeys {
+		feature.variables = append(feature.variables, builtVariable{key: variableKey, value: variables[variableKey]})
+	}
+	b.features = append(b.features, feature)
+	return b
+}
+
+// Build returns the config as served by the config CDN.
+func (b *ConfigBuilder) Build() []byte {
+	b.nextId = 0
+	features := make([]map[string]interface{}, 0, len(b.features))
+	variables := make([]map[string]interface{}, 0)
+
+	for _, feature := range b.features {
+		variationId := b.id()
+		variationVariables := make([]map[string]interface{}, 0, len(feature.variables))
+		for _, variable := range feature.variables {
+			variableId := b.id()
+			variables = append(variables, map[string]interface{}{
+				"_id":  variableId,
+				"key":  variable.key,
+				"type": variableType(variable.value),
+			})
+			variationVariables = append(variationVariables, map[string]interface{}{
+				"_var":  variableId,
+				"value": variable.value,
+			})
+		}
+
+		features = append(features, map[string]interface{}{
+			"_id":  b.id(),
+			"key":  feature.key,
+			"type": "release",
+			"variations": []map[string]interface{}{{
+				"_id":       variationId,
+				"key":       "variation-on",
+				"name":      "Variation On",
+				"variables": variationVariables,
+			}},
+			"configuration": map[string]interface{}{
+				"_id": b.id(),
+				"targets": []map[string]interface{}{{
+					"_id": b.id(),
+					"_audience": map[string]interface{}{
+						"_id": b.id(),
+						"filters": map[string]interface{}{
+							"operator": "and",
+							"filters":  []map[string]interface{}{{"type": "all", "values": []string{}, "filters": []interface{}{}}},
+						},
+					},
+					"distribution": []map[string]interface{}{{"percentage": 1, "_variation": variationId}},
+				}},
+				"forcedUsers": map[string]interface{}{},
+			},
+		})
+	}
+
+	config, err := json.Marshal(map[string]interface{}{
+		"project": map[string]interface{}{
+			"_id":             b.id(),
+			"key":             "devcycletest",
+			"a0_organization": "org_devcycletest",
+			"settings": map[string]interface{}{
+				"edgeDB": map[string]interface{}{"enabled": false},
+				"optIn":  map[string]interface{}{"enabled": false},
+			},
+		},
+		"environment": map[string]interface{}{
+			"_id": b.id(),
+			"key": "development",
+		},
+		"features":  features,
+		"variables": variables,
+	})
+	if err != nil {
+		panic(fmt.Sprintf("devcycletest: variable values must be JSON serializable: %v", err))
+	}
+	return config
+}
+
+func (b *ConfigBuilder) id() string {
+	b.nextId++
+	return fmt.Sprintf("%024x", b.nextId)
+}
+
+func variableType(value interface{}) string {
+	switch value.(type) {
+	case bool:
+		return "Boolean"
+	case string:
+		return "String"
+	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, float32, float64:
+		return "Number"
+	default:
+		return "JSON"
+	}
+}
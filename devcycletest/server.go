@@ -0,0 +1,297 @@
+// Package devcycletest provides a local stand-in for the DevCycle config CDN and events API,
+// for testing code that uses the DevCycle SDK in local bucketing mode.
+package devcycletest
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"time"
+
+	devcycle "github.com/BIwashi/go-server-sdk/v2"
+	"github.com/BIwashi/go-server-sdk/v2/api"
+)
+
+// SDKKey is a server SDK key accepted by the SDK. The server serves its config for any SDK key.
+const SDKKey = "dvc_server_devcycletest"
+
+// Server serves a config with ETag and If-None-Match semantics on the config CDN endpoint, records
+// every batch posted to the events API endpoint, and announces config updates on a Server-Sent Events endpoint.
+type Server struct {
+	httpServer *httptest.Server
+	closing    chan struct{}
+	closeOnce  sync.Once
+
+	mutex          sync.Mutex
+	config         []byte
+	configVersion  int
+	configStatus   int
+	eventsStatus   int
+	configRequests int
+	batches        []api.BatchEventsBody
+	streams        map[*configStream]struct{}
+}
+
+type configStream struct {
+	messages   chan string
+	disconnect chan struct{}
+}
+
+// NewServer starts a server serving config, which can be nil to fail config requests with a 404 until
+// SetConfig is called. Close the server once the test is done.
+func NewServer(config []byte) *Server {
+	s := &Server{
+		closing: make(chan struct{}),
+		streams: make(map[*configStream]struct{}),
+	}
+	s.SetConfig(config)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/config/v1/server/", s.handleConfig)
+	mux.HandleFunc("/v1/events/batch", s.handleEvents)
+	mux.HandleFunc("/v1/stream", s.handleStream)
+	s.httpServer = httptest.NewServer(mux)
+	return s
+}
+
+// URL is the base URL of the server.
+func (s *Server) URL() string {
+	return s.httpServer.URL
+}
+
+// StreamURL is the URL of the Server-Sent Events endpoint, to use as Options.ConfigStreamURI.
+func (s *Server) StreamURL() string {
+	return s.httpServer.URL + "/v1/stream"
+}
+
+// Close stops the server and disconnects its streams. Calling it again does nothing.
+func (s *Server) Close() {
+	s.closeOnce.Do(func() {
+		close(s.closing)
+		s.httpServer.Close()
+	})
+}
+
+// Options returns SDK options pointed at the server, with the shortest polling and flush intervals allowed.
+func (s *Server) Options() *devcycle.Options {
+	return &devcycle.Options{
+		ConfigCDNURI:            s.URL(),
+		EventsAPIURI:            s.URL(),
+		ConfigPollingIntervalMS: time.Second,
+		EventFlushIntervalMS:    500 * time.Millisecond,
+	}
+}
+
+// SetConfig replaces the served config and its ETag. Clients pick it up on their next poll.
+func (s *Server) SetConfig(config []byte) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.config = config
+	s.configVersion++
+}
+
+// ETag is the ETag of the served config.
+func (s *Server) ETag() string {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.eTag()
+}
+
+func (s *Server) eTag() string {
+	return fmt.Sprintf(`"devcycletest-%d"`, s.configVersion)
+}
+
+// FailConfigRequests responds to config requests with statusCode, for example 500 or 403.
+// A statusCode of 0 serves the config again.
+func (s *Server) FailConfigRequests(statusCode int) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.configStatus = statusCode
+}
+
+// FailEventRequests responds to event batches with statusCode without recording them.
+// A statusCode of 0 accepts batches again.
+func (s *Server) FailEventRequests(statusCode int) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.eventsStatus = statusCode
+}
+
+// ConfigRequests is the number of requests received by the config endpoint.
+func (s *Server) ConfigRequests() int {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.configRequests
+}
+
+// Batches returns every batch accepted by the events endpoint, in the order received.
+func (s *Server) Batches() []api.BatchEventsBody {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	batches := make([]api.BatchEventsBody, len(s.batches))
+	copy(batches, s.batches)
+	return batches
+}
+
+// Events returns every event accepted by the events endpoint, in the order received.
+func (s *Server) Events() []api.Event {
+	var events []api.Event
+	for _, batch := range s.Batches() {
+		for _, record := range batch.Batch {
+			events = append(events, record.Events...)
+		}
+	}
+	return events
+}
+
+// EventsOfType returns the accepted events of the given type, such as api.EventType_AggVariableEvaluated.
+func (s *Server) EventsOfType(eventType string) []api.Event {
+	var events []api.Event
+	for _, event := range s.Events() {
+		if event.Type_ == eventType {
+			events = append(events, event)
+		}
+	}
+	return events
+}
+
+// StreamConnections is the number of clients currently connected to the stream endpoint.
+func (s *Server) StreamConnections() int {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return len(s.streams)
+}
+
+// AnnounceConfigUpdate sends a configUpdated event with the ETag of the served config to every connected client,
+// which then fetches the config from the config endpoint. With inline set, the event carries the config itself.
+func (s *Server) AnnounceConfigUpdate(inline bool) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	message := map[string]interface{}{"etag": s.eTag()}
+	if inline {
+		message["config"] = json.RawMessage(s.config)
+	}
+	data, err := json.Marshal(message)
+	if err != nil {
+		return err
+	}
+	for stream := range s.streams {
+		select {
+		case stream.messages <- string(data):
+		default:
+		}
+	}
+	return nil
+}
+
+// DisconnectStreams ends the connection of every client connected to the stream endpoint.
+func (s *Server) DisconnectStreams() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	for stream := range s.streams {
+		close(stream.disconnect)
+		delete(s.streams, stream)
+	}
+}
+
+// ResetEvents forgets the batches received so far.
+func (s *Server) ResetEvents() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.batches = nil
+}
+
+func (s *Server) handleConfig(w http.ResponseWriter, r *http.Request) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.configRequests++
+
+	if r.Method != http.MethodGet || !strings.HasSuffix(r.URL.Path, ".json") {
+		http.NotFound(w, r)
+		return
+	}
+	if s.configStatus != 0 {
+		w.WriteHeader(s.configStatus)
+		return
+	}
+	if s.config == nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	eTag := s.eTag()
+	w.Header().Set("ETag", eTag)
+	if r.Header.Get("If-None-Match") == eTag {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write(s.config)
+}
+
+func (s *Server) handleEvents(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.eventsStatus != 0 {
+		w.WriteHeader(s.eventsStatus)
+		return
+	}
+
+	var batch api.BatchEventsBody
+	err := json.NewDecoder(r.Body).Decode(&batch)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	s.batches = append(s.batches, batch)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	_, _ = w.Write([]byte(`{}`))
+}
+
+func (s *Server) handleStream(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+
+	stream := &configStream{messages: make(chan string, 16), disconnect: make(chan struct{})}
+	s.mutex.Lock()
+	s.streams[stream] = struct{}{}
+	s.mutex.Unlock()
+	defer func() {
+		s.mutex.Lock()
+		defer s.mutex.Unlock()
+		delete(s.streams, stream)
+	}()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write([]byte(": connected\n\n"))
+	flusher.Flush()
+
+	for {
+		select {
+		case data := <-stream.messages:
+			_, _ = fmt.Fprintf(w, "event: configUpdated\ndata: %s\n\n", data)
+			flusher.Flush()
+		case <-stream.disconnect:
+			return
+		case <-r.Context().Done():
+			return
+		case <-s.closing:
+			return
+		}
+	}
+}
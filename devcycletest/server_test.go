@@ -0,0 +1,138 @@
+package devcycletest
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	devcycle "github.com/BIwashi/go-server-sdk/v2"
+	"github.com/BIwashi/go-server-sdk/v2/api"
+	"github.com/stretchr/testify/require"
+)
+
+var testUser = devcycle.User{UserId: "devcycletest-user"}
+
+func TestServer_ServesAndSwapsConfig(t *testing.T) {
+	server := NewServer(NewConfigBuilder().Variable("string-var", "first").Build())
+	defer server.Close()
+
+	client, err := devcycle.NewClient(SDKKey, server.Options())
+	require.NoError(t, err)
+	defer client.Close()
+
+	value, err := client.StringVariable(testUser, "string-var", "default")
+	require.NoError(t, err)
+	require.Equal(t, "first", value)
+
+	_, eTag, err := client.GetRawConfig()
+	require.NoError(t, err)
+	require.Equal(t, server.ETag(), eTag)
+
+	server.SetConfig(NewConfigBuilder().
+		Variable("string-var", "second").
+		Feature("feature", map[string]interface{}{"bool-var": true, "json-var": map[string]interface{}{"a": 1}}).
+		Build())
+	require.Eventually(t, func() bool {
+		value, err := client.StringVariable(testUser, "string-var", "default")
+		return err == nil && value == "second"
+	}, 10*time.Second, 50*time.Millisecond)
+
+	boolValue, err := client.BoolVariable(testUser, "bool-var", false)
+	require.NoError(t, err)
+	require.True(t, boolValue)
+
+	// Unchanged configs are answered with a 304 and not stored again
+	requests := server.ConfigRequests()
+	require.Eventually(t, func() bool { return server.ConfigRequests() > requests }, 10*time.Second, 50*time.Millisecond)
+	_, eTag, err = client.GetRawConfig()
+	require.NoError(t, err)
+	require.Equal(t, server.ETag(), eTag)
+}
+
+func TestServer_StreamsConfigUpdates(t *testing.T) {
+	server := NewServer(NewConfigBuilder().Variable("string-var", "first").Build())
+	defer server.Close()
+
+	options := server.Options()
+	options.ConfigPollingIntervalMS = time.Minute
+	options.ConfigStreamURI = server.StreamURL()
+	client, err := devcycle.NewClient(SDKKey, options)
+	require.NoError(t, err)
+	defer client.Close()
+	require.Eventually(t, func() bool { return server.StreamConnections() == 1 }, 5*time.Second, 10*time.Millisecond)
+
+	server.SetConfig(NewConfigBuilder().Variable("string-var", "fetched").Build())
+	require.NoError(t, server.AnnounceConfigUpdate(false))
+	require.Eventually(t, func() bool {
+		value, err := client.StringVariable(testUser, "string-var", "default")
+		return err == nil && value == "fetched"
+	}, 5*time.Second, 10*time.Millisecond)
+
+	requests := server.ConfigRequests()
+	server.SetConfig(NewConfigBuilder().Variable("string-var", "inline").Build())
+	require.NoError(t, server.AnnounceConfigUpdate(true))
+	require.Eventually(t, func() bool {
+		value, err := client.StringVariable(testUser, "string-var", "default")
+		return err == nil && value == "inline"
+	}, 5*time.Second, 10*time.Millisecond)
+	require.Equal(t, requests, server.ConfigRequests())
+}
+
+func TestServer_ConfigFailures(t *testing.T) {
+	server := NewServer(NewConfigBuilder().Variable("string-var", "on").Build())
+	defer server.Close()
+
+	server.FailConfigRequests(http.StatusForbidden)
+	_, err := devcycle.NewClient(SDKKey, server.Options())
+	require.Error(t, err)
+
+	server.FailConfigRequests(http.StatusInternalServerError)
+	client, err := devcycle.NewClient(SDKKey, server.Options())
+	require.NoError(t, err)
+	defer client.Close()
+
+	variable, err := client.Variable(testUser, "string-var", "default")
+	require.NoError(t, err)
+	require.Equal(t, devcycle.EvalReasonNotInitialized, variable.Eval.Reason)
+
+	server.FailConfigRequests(0)
+	require.Eventually(t, func() bool {
+		value, err := client.StringVariable(testUser, "string-var", "default")
+		return err == nil && value == "on"
+	}, 10*time.Second, 50*time.Millisecond)
+}
+
+func TestServer_RecordsEvents(t *testing.T) {
+	server := NewServer(NewConfigBuilder().Variable("string-var", "on").Build())
+	defer server.Close()
+
+	client, err := devcycle.NewClient(SDKKey, server.Options())
+	require.NoError(t, err)
+	defer client.Close()
+
+	server.FailEventRequests(http.StatusInternalServerError)
+	_, err = client.Track(testUser, devcycle.Event{Type_: "customEvent", Target: "checkout"})
+	require.NoError(t, err)
+	require.NoError(t, client.FlushEvents())
+	require.Empty(t, server.Batches())
+
+	// Payloads that failed with a 5xx are retried on the next flush
+	server.FailEventRequests(0)
+	require.Eventually(t, func() bool {
+		require.NoError(t, client.FlushEvents())
+		return len(server.EventsOfType(api.EventType_CustomEvent)) == 1
+	}, 10*time.Second, 50*time.Millisecond)
+
+	event := server.EventsOfType(api.EventType_CustomEvent)[0]
+	require.Equal(t, "checkout", event.Target)
+	require.Equal(t, testUser.UserId, event.UserId)
+
+	server.ResetEvents()
+	require.Empty(t, server.Events())
+}
+
+func TestServer_CloseTwice(t *testing.T) {
+	server := NewServer(nil)
+	server.Close()
+	require.NotPanics(t, server.Close)
+}
@@ -2,6 +2,7 @@ package devcycle
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -118,6 +119,11 @@ func (e *EventManager) QueueVariableDefaultedEvent(variableKey string) error {
 }
 
 func (e *EventManager) FlushEvents() (err error) {
+	return e.FlushEventsCtx(context.Background())
+}
+
+// FlushEventsCtx flushes the event queue, abandoning in-flight requests to the events API when ctx is done.
+func (e *EventManager) FlushEventsCtx(ctx context.Context) (err error) {
 	e.flushMutex.Lock()
 	defer e.flushMutex.Unlock()
 
@@ -131,7 +137,7 @@ func (e *EventManager) FlushEvents() (err error) {
 	}()
 
 	err = e.internalQueue.FlushEventQueue(func(payloads map[string]FlushPayload) (result *FlushResult, err error) {
-		return e.flushEventPayloads(payloads)
+		return e.flushEventPayloads(ctx, payloads)
 	})
 
 	if err != nil {
@@ -144,6 +150,7 @@ func (e *EventManager) FlushEvents() (err error) {
 }
 
 func (e *EventManager) flushEventPayload(
+	ctx context.Context,
 	payload *FlushPayload,
 	successes *[]string,
 	failures *[]string,
@@ -158,7 +165,7 @@ func (e *EventManager) flushEventPayload(
 		e.reportPayloadFailure(payload, false, failures, retryableFailures)
 		return
 	}
-	req, err = http.NewRequest("POST", eventsHost+"/v1/events/batch", bytes.NewReader(requestBody))
+	req, err = http.NewRequestWithContext(ctx, "POST", eventsHost+"/v1/events/batch", bytes.NewReader(requestBody))
 	if err != nil {
 		util.Errorf("Failed to create request to events api: %s", err)
 		e.reportPayloadFailure(payload, false, failures, retryableFailures)
@@ -215,13 +222,18 @@ func (e *EventManager) flushEventPayload(
 	e.reportPayloadFailure(payload, false, failures, retryableFailures)
 }
 
-func (e *EventManager) flushEventPayloads(payloads map[string]FlushPayload) (result *FlushResult, err error) {
+func (e *EventManager) flushEventPayloads(ctx context.Context, payloads map[string]FlushPayload) (result *FlushResult, err error) {
 	successes := make([]string, 0, len(payloads))
 	failures := make([]string, 0)
 	retryableFailures := make([]string, 0)
 
 	for _, payload := range payloads {
-		e.flushEventPayload(&payload, &successes, &failures, &retryableFailures)
+		if e.options.DisableEventDelivery {
+			// Drop the payload so the queue keeps draining without contacting the events API
+			e.reportPayloadSuccess(&payload, &successes)
+			continue
+		}
+		e.flushEventPayload(ctx, &payload, &successes, &failures, &retryableFailures)
 	}
 
 	return &FlushResult{
@@ -0,0 +1,138 @@
+package geoip
+
+import (
+	"fmt"
+	"net/netip"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/BIwashi/go-server-sdk/v2/util"
+)
+
+// DefaultReloadInterval is how often a Database checks whether its file has changed.
+const DefaultReloadInterval = time.Minute
+
+// maxCachedLookups bounds the lookup cache, which is emptied when it is full.
+const maxCachedLookups = 10000
+
+// Database looks up IPs in a database file, and reloads the file when its size or modification
+// time changes. Lookups are cached until the file is reloaded.
+type Database struct {
+	path     string
+	snapshot atomic.Pointer[snapshot]
+	modTime  time.Time
+	size     int64
+
+	closeOnce sync.Once
+	shutdown  chan struct{}
+	stopped   sync.WaitGroup
+}
+
+// snapshot is a loaded database file and the lookups made in it.
+type snapshot struct {
+	reader *Reader
+
+	cacheMutex sync.RWMutex
+	cache      map[string]cachedLookup
+}
+
+type cachedLookup struct {
+	location Location
+	found    bool
+}
+
+// Open loads the database file and checks it for changes every reloadInterval. A reloadInterval of
+// zero or less disables reloading.
+func Open(path string, reloadInterval time.Duration) (*Database, error) {
+	d := &Database{path: path, shutdown: make(chan struct{})}
+	if _, err := d.reloadIfChanged(); err != nil {
+		return nil, err
+	}
+	if reloadInterval > 0 {
+		d.stopped.Add(1)
+		go d.watch(reloadInterval)
+	}
+	return d, nil
+}
+
+// Lookup returns the location of the IP address, and false if the address is invalid or not in
+// the database.
+func (d *Database) Lookup(ip string) (Location, bool) {
+	s := d.snapshot.Load()
+	s.cacheMutex.RLock()
+	cached, ok := s.cache[ip]
+	s.cacheMutex.RUnlock()
+	if ok {
+		return cached.location, cached.found
+	}
+
+	var location Location
+	found := false
+	if addr, err := netip.ParseAddr(ip); err == nil {
+		location, found, err = s.reader.Lookup(addr)
+		if err != nil {
+			util.Warnf("GeoIP lookup of %s failed: %s", ip, err)
+		}
+	}
+
+	s.cacheMutex.Lock()
+	if len(s.cache) >= maxCachedLookups {
+		s.cache = make(map[string]cachedLookup)
+	}
+	s.cache[ip] = cachedLookup{location: location, found: found}
+	s.cacheMutex.Unlock()
+	return location, found
+}
+
+// Close stops checking the file for changes.
+func (d *Database) Close() {
+	d.closeOnce.Do(func() {
+		close(d.shutdown)
+	})
+	d.stopped.Wait()
+}
+
+func (d *Database) watch(interval time.Duration) {
+	defer d.stopped.Done()
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-d.shutdown:
+			return
+		case <-ticker.C:
+			reloaded, err := d.reloadIfChanged()
+			if err != nil {
+				util.Warnf("Keeping the previous GeoIP database: %s", err)
+			} else if reloaded {
+				util.Infof("Reloaded GeoIP database %s", d.path)
+			}
+		}
+	}
+}
+
+// reloadIfChanged loads the file if it changed since it was last loaded. It is only called by Open
+// and the watch goroutine, so the file state needs no lock.
+func (d *Database) reloadIfChanged() (bool, error) {
+	info, err := os.Stat(d.path)
+	if err != nil {
+		return false, err
+	}
+	if d.snapshot.Load() != nil && info.ModTime().Equal(d.modTime) && info.Size() == d.size {
+		return false, nil
+	}
+
+	buffer, err := os.ReadFile(d.path)
+	if err != nil {
+		return false, err
+	}
+	reader, err := NewReader(buffer)
+	if err != nil {
+		return false, fmt.Errorf("%s: %w", d.path, err)
+	}
+	d.snapshot.Store(&snapshot{reader: reader, cache: make(map[string]cachedLookup)})
+	d.modTime, d.size = info.ModTime(), info.Size()
+	return true, nil
+}
@@ -0,0 +1,88 @@
+// Package geoip looks up the location of IP addresses in MaxMind DB (.mmdb) files, such as the
+// GeoLite2 and GeoIP2 City and Country databases. Files are read with maxminddb-golang, which trusts
+// them to be well formed, so only load databases from MaxMind or another trusted source.
+package geoip
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/netip"
+
+	"github.com/oschwald/maxminddb-golang"
+)
+
+var ErrInvalidDatabase = errors.New("invalid MaxMind database")
+
+// Location is the part of a database record used for targeting.
+type Location struct {
+	// Country is the ISO 3166-1 alpha-2 code of the country
+	Country string
+	// Region is the ISO 3166-2 code of the most specific subdivision, without the country prefix
+	Region string
+	// City is the English name of the city
+	City string
+}
+
+// Reader reads a MaxMind DB held in memory. It is safe for concurrent use.
+type Reader struct {
+	db *maxminddb.Reader
+}
+
+// record holds the fields of City and Country database records that a Location is made of.
+type record struct {
+	Country struct {
+		ISOCode string `maxminddb:"iso_code"`
+	} `maxminddb:"country"`
+	RegisteredCountry struct {
+		ISOCode string `maxminddb:"iso_code"`
+	} `maxminddb:"registered_country"`
+	Subdivisions []struct {
+		ISOCode string `maxminddb:"iso_code"`
+	} `maxminddb:"subdivisions"`
+	City struct {
+		Names struct {
+			English string `maxminddb:"en"`
+		} `maxminddb:"names"`
+	} `maxminddb:"city"`
+}
+
+// NewReader parses the metadata of a MaxMind DB file.
+func NewReader(buffer []byte) (*Reader, error) {
+	db, err := maxminddb.FromBytes(buffer)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidDatabase, err)
+	}
+	return &Reader{db: db}, nil
+}
+
+// Lookup returns the location of the address, and false if the database has no record for it.
+func (r *Reader) Lookup(addr netip.Addr) (Location, bool, error) {
+	addr = addr.Unmap()
+	if !addr.IsValid() || (addr.Is6() && r.db.Metadata.IPVersion == 4) {
+		return Location{}, false, nil
+	}
+	offset, err := r.db.LookupOffset(net.IP(addr.AsSlice()))
+	if err != nil {
+		return Location{}, false, fmt.Errorf("%w: %s", ErrInvalidDatabase, err)
+	}
+	if offset == maxminddb.NotFound {
+		return Location{}, false, nil
+	}
+	var fields record
+	if err := r.db.Decode(offset, &fields); err != nil {
+		return Location{}, false, fmt.Errorf("%w: record: %s", ErrInvalidDatabase, err)
+	}
+	return fields.location(), true, nil
+}
+
+func (r record) location() Location {
+	location := Location{Country: r.Country.ISOCode, City: r.City.Names.English}
+	if location.Country == "" {
+		location.Country = r.RegisteredCountry.ISOCode
+	}
+	if len(r.Subdivisions) > 0 {
+		location.Region = r.Subdivisions[len(r.Subdivisions)-1].ISOCode
+	}
+	return location
+}
@@ -0,0 +1,110 @@
+package geoip
+
+import (
+	"net/netip"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func writeTestDatabase(t testing.TB, recordSize int) []byte {
+	w := newTestWriter(recordSize)
+	w.insert(t, "203.0.113.0/24", cityRecord("CA", "QC", "Montreal"))
+	w.insert(t, "198.51.100.0/25", cityRecord("US", "CA", "San Francisco"))
+	w.insert(t, "198.51.100.128/25", cityRecord("US", "", ""))
+	w.insert(t, "2001:db8::/32", cityRecord("GB", "ENG", "London"))
+	w.insert(t, "192.0.2.0/24", map[string]interface{}{
+		"registered_country": map[string]interface{}{"iso_code": "FR"},
+		"is_anycast":         true,
+	})
+	return w.bytes(t)
+}
+
+func TestReader_Lookup(t *testing.T) {
+	for _, recordSize := range []int{24, 28, 32} {
+		reader, err := NewReader(writeTestDatabase(t, recordSize))
+		require.NoError(t, err)
+
+		testCases := []struct {
+			ip       string
+			location Location
+			found    bool
+		}{
+			{"203.0.113.42", Location{Country: "CA", Region: "QC", City: "Montreal"}, true},
+			{"::ffff:203.0.113.42", Location{Country: "CA", Region: "QC", City: "Montreal"}, true},
+			{"198.51.100.1", Location{Country: "US", Region: "CA", City: "San Francisco"}, true},
+			{"198.51.100.200", Location{Country: "US"}, true},
+			{"2001:db8:1234::1", Location{Country: "GB", Region: "ENG", City: "London"}, true},
+			{"192.0.2.1", Location{Country: "FR"}, true},
+			{"8.8.8.8", Location{}, false},
+			{"2001:db9::1", Location{}, false},
+		}
+		for _, tc := range testCases {
+			location, found, err := reader.Lookup(netip.MustParseAddr(tc.ip))
+			require.NoError(t, err, tc.ip)
+			require.Equal(t, tc.found, found, "record size %d, %s", recordSize, tc.ip)
+			require.Equal(t, tc.location, location, "record size %d, %s", recordSize, tc.ip)
+		}
+	}
+}
+
+func TestReader_InvalidDatabase(t *testing.T) {
+	_, err := NewReader([]byte("not a database"))
+	require.ErrorIs(t, err, ErrInvalidDatabase)
+
+	database := writeTestDatabase(t, 24)
+	_, err = NewReader(database[len(database)/2:])
+	require.ErrorIs(t, err, ErrInvalidDatabase)
+
+	// Records that aren't shaped like City or Country records fail the lookup
+	w := newTestWriter(24)
+	w.insert(t, "203.0.113.0/24", map[string]interface{}{"country": "CA"})
+	reader, err := NewReader(w.bytes(t))
+	require.NoError(t, err)
+	_, found, err := reader.Lookup(netip.MustParseAddr("203.0.113.42"))
+	require.ErrorIs(t, err, ErrInvalidDatabase)
+	require.False(t, found)
+}
+
+func TestReader_TestdataDatabase(t *testing.T) {
+	// ../testdata/geoip-test.mmdb is used by the client tests
+	buffer, err := os.ReadFile(filepath.Join("..", "testdata", "geoip-test.mmdb"))
+	require.NoError(t, err)
+	require.Equal(t, writeTestDatabase(t, 24), buffer)
+}
+
+func TestDatabase_ReloadsChangedFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.mmdb")
+	require.NoError(t, os.WriteFile(path, writeTestDatabase(t, 24), 0o600))
+	database, err := Open(path, 10*time.Millisecond)
+	require.NoError(t, err)
+	defer database.Close()
+
+	location, found := database.Lookup("203.0.113.42")
+	require.True(t, found)
+	require.Equal(t, "CA", location.Country)
+	_, found = database.Lookup("not-an-ip")
+	require.False(t, found)
+
+	// Invalid files are ignored until they are replaced by a valid one
+	require.NoError(t, os.WriteFile(path, []byte("partially written"), 0o600))
+	time.Sleep(50 * time.Millisecond)
+	location, _ = database.Lookup("203.0.113.42")
+	require.Equal(t, "CA", location.Country)
+
+	w := newTestWriter(24)
+	w.insert(t, "203.0.113.0/24", cityRecord("MX", "", ""))
+	require.NoError(t, os.WriteFile(path, w.bytes(t), 0o600))
+	require.Eventually(t, func() bool {
+		location, _ := database.Lookup("203.0.113.42")
+		return location.Country == "MX"
+	}, 5*time.Second, 10*time.Millisecond)
+}
+
+func TestDatabase_OpenMissingFile(t *testing.T) {
+	_, err := Open(filepath.Join(t.TempDir(), "missing.mmdb"), 0)
+	require.Error(t, err)
+}
@@ -0,0 +1,246 @@
+package geoip
+
+import (
+	"bytes"
+	"encoding/binary"
+	"net/netip"
+	"sort"
+	"testing"
+)
+
+// metadataStartMarker precedes the metadata map at the end of the file.
+var metadataStartMarker = []byte("\xAB\xCD\xEFMaxMind.com")
+
+// dataSectionSeparatorSize is the number of zero bytes between the search tree and the data section.
+const dataSectionSeparatorSize = 16
+
+// Data section types, see https://maxmind.github.io/MaxMind-DB/
+const (
+	typeExtended = iota
+	typePointer
+	typeString
+	typeDouble
+	typeBytes
+	typeUint16
+	typeUint32
+	typeMap
+	typeInt32
+	typeUint64
+	typeUint128
+	typeArray
+	typeContainer
+	typeEndMarker
+	typeBool
+	typeFloat
+)
+
+// testWriter builds small MaxMind DB files with an IPv6 search tree. Repeated strings are written
+// once and referenced with pointers, like the MaxMind writer does.
+type testWriter struct {
+	recordSize int
+	root       *testNode
+	data       bytes.Buffer
+	strings    map[string]int
+}
+
+type testNode struct {
+	children [2]*testNode
+	// records holds the data offset of a network ending at this node, or -1
+	records [2]int
+}
+
+func newTestNode() *testNode {
+	return &testNode{records: [2]int{-1, -1}}
+}
+
+func newTestWriter(recordSize int) *testWriter {
+	return &testWriter{recordSize: recordSize, root: newTestNode(), strings: map[string]int{}}
+}
+
+// insert adds a network. IPv4 networks are stored under ::/96.
+func (w *testWriter) insert(t testing.TB, network string, record map[string]interface{}) {
+	prefix := netip.MustParsePrefix(network)
+	ip := prefix.Addr().As16()
+	bits := prefix.Bits()
+	if prefix.Addr().Is4() {
+		ip = [16]byte{}
+		copy(ip[12:], prefix.Addr().AsSlice())
+		bits += 96
+	}
+
+	offset := w.data.Len()
+	w.encode(t, record)
+
+	node := w.root
+	for i := 0; i < bits; i++ {
+		bit := (ip[i/8] >> (7 - i%8)) & 1
+		if i == bits-1 {
+			node.records[bit] = offset
+			return
+		}
+		if node.children[bit] == nil {
+			node.children[bit] = newTestNode()
+		}
+		node = node.children[bit]
+	}
+}
+
+func (w *testWriter) bytes(t testing.TB) []byte {
+	var nodes []*testNode
+	index := map[*testNode]int{}
+	var number func(node *testNode)
+	number = func(node *testNode) {
+		index[node] = len(nodes)
+		nodes = append(nodes, node)
+		for _, child := range node.children {
+			if child != nil {
+				number(child)
+			}
+		}
+	}
+	number(w.root)
+	nodeCount := len(nodes)
+
+	var out bytes.Buffer
+	for _, node := range nodes {
+		var records [2]uint32
+		for bit := 0; bit < 2; bit++ {
+			switch {
+			case node.children[bit] != nil:
+				records[bit] = uint32(index[node.children[bit]])
+			case node.records[bit] >= 0:
+				records[bit] = uint32(nodeCount + dataSectionSeparatorSize + node.records[bit])
+			default:
+				records[bit] = uint32(nodeCount)
+			}
+		}
+		switch w.recordSize {
+		case 24:
+			out.Write([]byte{byte(records[0] >> 16), byte(records[0] >> 8), byte(records[0])})
+			out.Write([]byte{byte(records[1] >> 16), byte(records[1] >> 8), byte(records[1])})
+		case 28:
+			out.Write([]byte{byte(records[0] >> 16), byte(records[0] >> 8), byte(records[0])})
+			out.WriteByte(byte(records[0]>>20)&0xF0 | byte(records[1]>>24)&0x0F)
+			out.Write([]byte{byte(records[1] >> 16), byte(records[1] >> 8), byte(records[1])})
+		case 32:
+			_ = binary.Write(&out, binary.BigEndian, records)
+		}
+	}
+	out.Write(make([]byte, dataSectionSeparatorSize))
+	out.Write(w.data.Bytes())
+	out.Write(metadataStartMarker)
+
+	metadata := &testWriter{strings: map[string]int{}}
+	metadata.encode(t, map[string]interface{}{
+		"node_count":                  uint32(nodeCount),
+		"record_size":                 uint32(w.recordSize),
+		"ip_version":                  uint32(6),
+		"database_type":               "Test-City",
+		"languages":                   []interface{}{"en"},
+		"binary_format_major_version": uint32(2),
+		"binary_format_minor_version": uint32(0),
+		"build_epoch":                 uint64(1700000000),
+	})
+	out.Write(metadata.data.Bytes())
+	return out.Bytes()
+}
+
+func (w *testWriter) encode(t testing.TB, value interface{}) {
+	switch value := value.(type) {
+	case string:
+		if offset, ok := w.strings[value]; ok {
+			w.writePointer(offset)
+			return
+		}
+		w.strings[value] = w.data.Len()
+		w.writeControl(typeString, len(value))
+		w.data.WriteString(value)
+	case uint32:
+		b := binary.BigEndian.AppendUint32(nil, value)
+		b = bytes.TrimLeft(b, "\x00")
+		w.writeControl(typeUint32, len(b))
+		w.data.Write(b)
+	case uint64:
+		b := binary.BigEndian.AppendUint64(nil, value)
+		b = bytes.TrimLeft(b, "\x00")
+		w.writeControl(typeUint64, len(b))
+		w.data.Write(b)
+	case float64:
+		w.writeControl(typeDouble, 8)
+		_ = binary.Write(&w.data, binary.BigEndian, value)
+	case bool:
+		size := 0
+		if value {
+			size = 1
+		}
+		w.writeControl(typeBool, size)
+	case []interface{}:
+		w.writeControl(typeArray, len(value))
+		for _, item := range value {
+			w.encode(t, item)
+		}
+	case map[string]interface{}:
+		keys := make([]string, 0, len(value))
+		for key := range value {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		w.writeControl(typeMap, len(value))
+		for _, key := range keys {
+			w.encode(t, key)
+			w.encode(t, value[key])
+		}
+	default:
+		t.Fatalf("can't encode %T", value)
+	}
+}
+
+func (w *testWriter) writeControl(valueType int, size int) {
+	var extra []byte
+	switch {
+	case size >= 65821:
+		extra = []byte{byte((size - 65821) >> 16), byte((size - 65821) >> 8), byte(size - 65821)}
+		size = 31
+	case size >= 285:
+		extra = []byte{byte((size - 285) >> 8), byte(size - 285)}
+		size = 30
+	case size >= 29:
+		extra = []byte{byte(size - 29)}
+		size = 29
+	}
+	if valueType > typeMap {
+		w.data.WriteByte(byte(size))
+		w.data.WriteByte(byte(valueType - 7))
+	} else {
+		w.data.WriteByte(byte(valueType<<5 | size))
+	}
+	w.data.Write(extra)
+}
+
+func (w *testWriter) writePointer(offset int) {
+	if offset < 2048 {
+		w.data.Write([]byte{typePointer<<5 | byte(offset>>8), byte(offset)})
+		return
+	}
+	offset -= 2048
+	w.data.Write([]byte{typePointer<<5 | 1<<3 | byte(offset>>16), byte(offset >> 8), byte(offset)})
+}
+
+// cityRecord builds a record in the layout of the GeoIP2 City database.
+func cityRecord(country string, region string, city string) map[string]interface{} {
+	record := map[string]interface{}{
+		"country": map[string]interface{}{"iso_code": country, "geoname_id": uint32(6251999)},
+		"location": map[string]interface{}{
+			"latitude":        45.5,
+			"longitude":       -73.5,
+			"accuracy_radius": uint32(20),
+		},
+	}
+	if region != "" {
+		record["subdivisions"] = []interface{}{map[string]interface{}{"iso_code": region}}
+	}
+	if city != "" {
+		record["city"] = map[string]interface{}{"names": map[string]interface{}{"en": city, "fr": city}}
+	}
+	return record
+}
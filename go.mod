@@ -8,6 +8,7 @@ require (
 	github.com/jarcoal/httpmock v1.2.0
 	github.com/matryer/try v0.0.0-20161228173917-9ac251b645a2
 	github.com/open-feature/go-sdk v1.8.0
+	github.com/oschwald/maxminddb-golang v1.12.0
 	github.com/stretchr/testify v1.8.4
 	github.com/twmb/murmur3 v1.1.7
 	google.golang.org/protobuf v1.29.1
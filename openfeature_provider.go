@@ -362,6 +362,8 @@ func createUserFromEvaluationContext(evalCtx openfeature.FlattenedContext) (User
 				user.AppBuild = value
 			} else if key == "deviceModel" {
 				user.DeviceModel = value
+			} else if key == "ip" {
+				user.IP = value
 			} else if key == openfeature.TargetingKey || key == DEVCYCLE_USER_ID_KEY {
 				// Ignore, already handled
 			} else {
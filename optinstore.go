@@ -0,0 +1,109 @@
+package devcycle
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"sync"
+)
+
+// memoryOptInStore keeps the opted-in features of each user in memory.
+type memoryOptInStore struct {
+	mutex  sync.RWMutex
+	optIns map[string]map[string]bool
+}
+
+// NewInMemoryOptInStore returns an OptInStore that keeps opt-ins in memory, so they are lost when the process exits.
+func NewInMemoryOptInStore() OptInStore {
+	return &memoryOptInStore{optIns: make(map[string]map[string]bool)}
+}
+
+func (s *memoryOptInStore) IsOptedIn(userId string, featureId string) bool {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	return s.optIns[userId][featureId]
+}
+
+func (s *memoryOptInStore) SetOptIn(userId string, featureId string, optedIn bool) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.set(userId, featureId, optedIn)
+	return nil
+}
+
+func (s *memoryOptInStore) set(userId string, featureId string, optedIn bool) {
+	if !optedIn {
+		delete(s.optIns[userId], featureId)
+		if len(s.optIns[userId]) == 0 {
+			delete(s.optIns, userId)
+		}
+		return
+	}
+	if s.optIns[userId] == nil {
+		s.optIns[userId] = make(map[string]bool)
+	}
+	s.optIns[userId][featureId] = true
+}
+
+// fileOptInStore keeps opt-ins in memory and saves them to a JSON file mapping user ids to feature ids
+// after every change.
+type fileOptInStore struct {
+	memoryOptInStore
+	path string
+}
+
+// NewFileOptInStore returns an OptInStore saved to the JSON file at path, loading the opt-ins already in the file.
+// The file is created by the first opt-in if it does not exist.
+func NewFileOptInStore(path string) (OptInStore, error) {
+	s := &fileOptInStore{memoryOptInStore: memoryOptInStore{optIns: make(map[string]map[string]bool)}, path: path}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var saved map[string][]string
+	err = json.Unmarshal(data, &saved)
+	if err != nil {
+		return nil, fmt.Errorf("invalid opt-in file %s: %w", path, err)
+	}
+	for userId, featureIds := range saved {
+		for _, featureId := range featureIds {
+			s.set(userId, featureId, true)
+		}
+	}
+	return s, nil
+}
+
+func (s *fileOptInStore) SetOptIn(userId string, featureId string, optedIn bool) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.optIns[userId][featureId] == optedIn {
+		return nil
+	}
+	s.set(userId, featureId, optedIn)
+
+	saved := make(map[string][]string, len(s.optIns))
+	for userId, features := range s.optIns {
+		featureIds := make([]string, 0, len(features))
+		for featureId := range features {
+			featureIds = append(featureIds, featureId)
+		}
+		sort.Strings(featureIds)
+		saved[userId] = featureIds
+	}
+	data, err := json.Marshal(saved)
+	if err == nil {
+		err = writeFileAtomically(s.path, data)
+	}
+	if err != nil {
+		// Keep the store consistent with the file
+		s.set(userId, featureId, !optedIn)
+		return fmt.Errorf("error saving opt-in file %s: %w", s.path, err)
+	}
+	return nil
+}
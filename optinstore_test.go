@@ -0,0 +1,84 @@
+package devcycle
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestInMemoryOptInStore(t *testing.T) {
+	store := NewInMemoryOptInStore()
+	require.False(t, store.IsOptedIn("user", "feature"))
+	require.NoError(t, store.SetOptIn("user", "feature", true))
+	require.True(t, store.IsOptedIn("user", "feature"))
+	require.False(t, store.IsOptedIn("user", "other-feature"))
+	require.False(t, store.IsOptedIn("other-user", "feature"))
+	require.NoError(t, store.SetOptIn("user", "feature", false))
+	require.False(t, store.IsOptedIn("user", "feature"))
+}
+
+func TestFileOptInStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "opt-ins", "opt-ins.json")
+	store, err := NewFileOptInStore(path)
+	require.NoError(t, err)
+	require.NoError(t, store.SetOptIn("user", "feature-b", true))
+	require.NoError(t, store.SetOptIn("user", "feature-a", true))
+	require.NoError(t, store.SetOptIn("other-user", "feature-a", true))
+	require.NoError(t, store.SetOptIn("other-user", "feature-a", false))
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	require.JSONEq(t, `{"user": ["feature-a", "feature-b"]}`, string(data))
+
+	reloaded, err := NewFileOptInStore(path)
+	require.NoError(t, err)
+	require.True(t, reloaded.IsOptedIn("user", "feature-a"))
+	require.True(t, reloaded.IsOptedIn("user", "feature-b"))
+	require.False(t, reloaded.IsOptedIn("other-user", "feature-a"))
+
+	require.NoError(t, os.WriteFile(path, []byte("{"), 0o600))
+	_, err = NewFileOptInStore(path)
+	require.Error(t, err)
+}
+
+func TestClient_OptIn(t *testing.T) {
+	store := NewInMemoryOptInStore()
+	c, err := NewClient(test_environmentKey, &Options{
+		Offline:              true,
+		BootstrapConfig:      targetedConfig(t, `{"type": "optIn"}`, "optIn"),
+		DisableEventDelivery: true,
+		OptInStore:           store,
+	})
+	require.NoError(t, err)
+	defer c.Close()
+
+	user := User{UserId: "beta-tester"}
+	value, err := c.StringVariable(user, "string-var", "off")
+	require.NoError(t, err)
+	require.Equal(t, "off", value)
+
+	require.NoError(t, c.OptIn(user, "variable-types"))
+	require.True(t, store.IsOptedIn("beta-tester", "6216422850294da359385e8b"))
+	value, err = c.StringVariable(user, "string-var", "off")
+	require.NoError(t, err)
+	require.Equal(t, "on", value)
+	value, err = c.StringVariable(User{UserId: "someone-else"}, "string-var", "off")
+	require.NoError(t, err)
+	require.Equal(t, "off", value)
+
+	require.NoError(t, c.OptOut(user, "variable-types"))
+	value, err = c.StringVariable(user, "string-var", "off")
+	require.NoError(t, err)
+	require.Equal(t, "off", value)
+
+	require.Error(t, c.OptIn(user, "no-such-feature"))
+	require.Error(t, c.OptIn(User{}, "variable-types"))
+}
+
+func TestClient_OptIn_Cloud(t *testing.T) {
+	c, err := NewClient(test_environmentKey, &Options{EnableCloudBucketing: true})
+	require.NoError(t, err)
+	require.Error(t, c.OptIn(User{UserId: "beta-tester"}, "variable-types"))
+}
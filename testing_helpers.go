@@ -22,6 +22,9 @@ var (
 	test_large_config          string
 	test_large_config_variable = "v-key-25"
 
+	//go:embed testdata/fixture_variable_types_config.json
+	test_variable_types_config string
+
 	test_options = &Options{
 		// use defaults that will be set by the CheckDefaults
 		EventFlushIntervalMS:    time.Second * 30,
@@ -34,6 +37,7 @@ func init() {
 	test_config = strings.ReplaceAll(test_config, "\n", "")
 	test_config_special_characters_var = strings.ReplaceAll(test_config_special_characters_var, "\n", "")
 	test_large_config = strings.ReplaceAll(test_large_config, "\n", "")
+	test_variable_types_config = strings.ReplaceAll(test_variable_types_config, "\n", "")
 
 	// Set default options
 	test_options.CheckDefaults()
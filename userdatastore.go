@@ -0,0 +1,178 @@
+package devcycle
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"sync"
+
+	"github.com/BIwashi/go-server-sdk/v2/api"
+)
+
+// memoryUserDataStore keeps the custom data of each user in memory.
+type memoryUserDataStore struct {
+	mutex sync.RWMutex
+	users map[string]map[string]interface{}
+}
+
+// NewInMemoryUserDataStore returns a UserDataStore that keeps user data in memory, so it is lost when the process
+// exits.
+func NewInMemoryUserDataStore() UserDataStore {
+	return &memoryUserDataStore{users: make(map[string]map[string]interface{})}
+}
+
+func (s *memoryUserDataStore) Get(userId string) (map[string]interface{}, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	return s.users[userId], nil
+}
+
+func (s *memoryUserDataStore) Merge(userId string, customData map[string]interface{}) (map[string]interface{}, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	merged, changed := api.MergeCustomData(s.users[userId], customData)
+	if changed {
+		s.set(userId, merged)
+	}
+	return merged, nil
+}
+
+func (s *memoryUserDataStore) set(userId string, customData map[string]interface{}) {
+	if len(customData) == 0 {
+		delete(s.users, userId)
+		return
+	}
+	stored := make(map[string]interface{}, len(customData))
+	for key, value := range customData {
+		stored[key] = value
+	}
+	s.users[userId] = stored
+}
+
+// compactionMargin is how many superseded records the user data file may hold on top of one per stored user
+// before it is rewritten.
+const compactionMargin = 1000
+
+// userDataRecord is a line of the user data file.
+type userDataRecord struct {
+	UserId     string                 `json:"user_id"`
+	CustomData map[string]interface{} `json:"customData"`
+}
+
+// fileUserDataStore keeps user data in memory and appends every change to a file of JSON lines, in which the last
+// record of a user wins. The file is rewritten with one record per user when superseded records pile up.
+type fileUserDataStore struct {
+	memoryUserDataStore
+	path    string
+	records int
+}
+
+// NewFileUserDataStore returns a UserDataStore saved to the append-only file at path, loading the user data already
+// in the file. The file is created by the first Merge if it does not exist.
+func NewFileUserDataStore(path string) (UserDataStore, error) {
+	s := &fileUserDataStore{memoryUserDataStore: memoryUserDataStore{users: make(map[string]map[string]interface{})}, path: path}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// A write interrupted by a crash leaves a last line without a newline, which is dropped unless it is complete
+	complete, partial := data, []byte(nil)
+	if i := bytes.LastIndexByte(data, '\n'); i != len(data)-1 {
+		complete, partial = data[:i+1], data[i+1:]
+	}
+	for _, line := range bytes.Split(complete, []byte("\n")) {
+		if len(bytes.TrimSpace(line)) == 0 {
+			continue
+		}
+		var record userDataRecord
+		err = json.Unmarshal(line, &record)
+		if err != nil {
+			return nil, fmt.Errorf("invalid user data file %s: %w", path, err)
+		}
+		s.set(record.UserId, record.CustomData)
+		s.records++
+	}
+	if len(partial) > 0 {
+		var record userDataRecord
+		if json.Unmarshal(partial, &record) == nil {
+			s.set(record.UserId, record.CustomData)
+		}
+		// Appending after the partial line would corrupt the next record
+		err = s.compact()
+		if err != nil {
+			return nil, fmt.Errorf("error rewriting user data file %s: %w", path, err)
+		}
+	}
+	return s, nil
+}
+
+func (s *fileUserDataStore) Merge(userId string, customData map[string]interface{}) (map[string]interface{}, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	merged, changed := api.MergeCustomData(s.users[userId], customData)
+	if !changed {
+		return merged, nil
+	}
+
+	data, err := json.Marshal(userDataRecord{UserId: userId, CustomData: merged})
+	if err != nil {
+		return merged, err
+	}
+	err = s.append(append(data, '\n'))
+	if err != nil {
+		return merged, fmt.Errorf("error saving user data file %s: %w", s.path, err)
+	}
+	s.set(userId, merged)
+	s.records++
+	if s.records > 2*len(s.users)+compactionMargin {
+		err = s.compact()
+		if err != nil {
+			return merged, fmt.Errorf("error compacting user data file %s: %w", s.path, err)
+		}
+	}
+	return merged, nil
+}
+
+func (s *fileUserDataStore) append(line []byte) error {
+	file, err := os.OpenFile(s.path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o600)
+	if err != nil {
+		return err
+	}
+	_, err = file.Write(line)
+	closeErr := file.Close()
+	if err != nil {
+		return err
+	}
+	return closeErr
+}
+
+// compact rewrites the file with the current record of each user.
+func (s *fileUserDataStore) compact() error {
+	userIds := make([]string, 0, len(s.users))
+	for userId := range s.users {
+		userIds = append(userIds, userId)
+	}
+	sort.Strings(userIds)
+
+	var buffer bytes.Buffer
+	encoder := json.NewEncoder(&buffer)
+	for _, userId := range userIds {
+		err := encoder.Encode(userDataRecord{UserId: userId, CustomData: s.users[userId]})
+		if err != nil {
+			return err
+		}
+	}
+	err := writeFileAtomically(s.path, buffer.Bytes())
+	if err != nil {
+		return err
+	}
+	s.records = len(userIds)
+	return nil
+}
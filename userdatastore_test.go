@@ -0,0 +1,172 @@
+package devcycle
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestInMemoryUserDataStore(t *testing.T) {
+	store := NewInMemoryUserDataStore()
+	data, err := store.Get("user")
+	require.NoError(t, err)
+	require.Nil(t, data)
+
+	customData := map[string]interface{}{"plan": "pro"}
+	merged, err := store.Merge("user", customData)
+	require.NoError(t, err)
+	require.Equal(t, map[string]interface{}{"plan": "pro"}, merged)
+	customData["plan"] = "free"
+	merged["plan"] = "free"
+	data, err = store.Get("user")
+	require.NoError(t, err)
+	require.Equal(t, map[string]interface{}{"plan": "pro"}, data)
+
+	merged, err = store.Merge("user", map[string]interface{}{"seats": 3.0})
+	require.NoError(t, err)
+	require.Equal(t, map[string]interface{}{"plan": "pro", "seats": 3.0}, merged)
+	merged, err = store.Merge("user", nil)
+	require.NoError(t, err)
+	require.Equal(t, map[string]interface{}{"plan": "pro", "seats": 3.0}, merged)
+}
+
+func TestInMemoryUserDataStore_ConcurrentMerges(t *testing.T) {
+	store := NewInMemoryUserDataStore()
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			_, err := store.Merge("user", map[string]interface{}{fmt.Sprintf("key-%d", i): float64(i)})
+			require.NoError(t, err)
+		}(i)
+	}
+	wg.Wait()
+
+	data, err := store.Get("user")
+	require.NoError(t, err)
+	require.Len(t, data, 50)
+}
+
+func TestFileUserDataStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "users.jsonl")
+	store, err := NewFileUserDataStore(path)
+	require.NoError(t, err)
+	_, err = store.Merge("user", map[string]interface{}{"plan": "free"})
+	require.NoError(t, err)
+	_, err = store.Merge("user", map[string]interface{}{"plan": "pro", "seats": 3.0})
+	require.NoError(t, err)
+	_, err = store.Merge("other-user", map[string]interface{}{"plan": "free"})
+	require.NoError(t, err)
+
+	// Merges that change nothing are not written
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	_, err = store.Merge("user", map[string]interface{}{"plan": "pro"})
+	require.NoError(t, err)
+	unchanged, err := os.Stat(path)
+	require.NoError(t, err)
+	require.Equal(t, info.Size(), unchanged.Size())
+
+	reloaded, err := NewFileUserDataStore(path)
+	require.NoError(t, err)
+	data, err := reloaded.Get("user")
+	require.NoError(t, err)
+	require.Equal(t, map[string]interface{}{"plan": "pro", "seats": 3.0}, data)
+	data, err = reloaded.Get("other-user")
+	require.NoError(t, err)
+	require.Equal(t, map[string]interface{}{"plan": "free"}, data)
+
+	// A partially written last record is dropped, and later records are still readable
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0o600)
+	require.NoError(t, err)
+	_, err = file.WriteString(`{"user_id": "user", "customData": {"pla`)
+	require.NoError(t, err)
+	require.NoError(t, file.Close())
+	reloaded, err = NewFileUserDataStore(path)
+	require.NoError(t, err)
+	data, err = reloaded.Get("user")
+	require.NoError(t, err)
+	require.Equal(t, map[string]interface{}{"plan": "pro", "seats": 3.0}, data)
+	_, err = reloaded.Merge("new-user", map[string]interface{}{"plan": "pro"})
+	require.NoError(t, err)
+	reloaded, err = NewFileUserDataStore(path)
+	require.NoError(t, err)
+	data, err = reloaded.Get("new-user")
+	require.NoError(t, err)
+	require.Equal(t, map[string]interface{}{"plan": "pro"}, data)
+
+	require.NoError(t, os.WriteFile(path, []byte("{\n"), 0o600))
+	_, err = NewFileUserDataStore(path)
+	require.Error(t, err)
+}
+
+func TestFileUserDataStore_Compaction(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "users.jsonl")
+	store, err := NewFileUserDataStore(path)
+	require.NoError(t, err)
+	for i := 0; i < 3*compactionMargin; i++ {
+		_, err = store.Merge("user", map[string]interface{}{"visits": float64(i)})
+		require.NoError(t, err)
+	}
+	require.LessOrEqual(t, store.(*fileUserDataStore).records, compactionMargin+2)
+
+	reloaded, err := NewFileUserDataStore(path)
+	require.NoError(t, err)
+	data, err := reloaded.Get("user")
+	require.NoError(t, err)
+	require.Equal(t, map[string]interface{}{"visits": float64(3*compactionMargin - 1)}, data)
+}
+
+// planFilter targets users with the "pro" plan in their custom data.
+const planFilter = `{"type": "user", "subType": "customData", "dataKey": "plan", "dataKeyType": "String", "comparator": "=", "values": ["pro"]}`
+
+func TestClient_UserDataStore(t *testing.T) {
+	store := NewInMemoryUserDataStore()
+	c, err := NewClient(test_environmentKey, &Options{
+		Offline:              true,
+		BootstrapConfig:      targetedConfig(t, planFilter, "edgeDB"),
+		DisableEventDelivery: true,
+		EnableEdgeDB:         true,
+		UserDataStore:        store,
+	})
+	require.NoError(t, err)
+	defer c.Close()
+
+	value, err := c.StringVariable(User{UserId: "customer"}, "string-var", "off")
+	require.NoError(t, err)
+	require.Equal(t, "off", value)
+
+	value, err = c.StringVariable(User{UserId: "customer", CustomData: map[string]interface{}{"plan": "pro"}}, "string-var", "off")
+	require.NoError(t, err)
+	require.Equal(t, "on", value)
+	value, err = c.StringVariable(User{UserId: "customer"}, "string-var", "off")
+	require.NoError(t, err)
+	require.Equal(t, "on", value)
+
+	data, err := store.Get("customer")
+	require.NoError(t, err)
+	require.Equal(t, map[string]interface{}{"plan": "pro"}, data)
+}
+
+func TestClient_UserDataStore_EdgeDBDisabled(t *testing.T) {
+	store := NewInMemoryUserDataStore()
+	c, err := NewClient(test_environmentKey, &Options{
+		Offline:              true,
+		BootstrapConfig:      targetedConfig(t, planFilter, "edgeDB"),
+		DisableEventDelivery: true,
+		UserDataStore:        store,
+	})
+	require.NoError(t, err)
+	defer c.Close()
+
+	_, err = c.StringVariable(User{UserId: "customer", CustomData: map[string]interface{}{"plan": "pro"}}, "string-var", "off")
+	require.NoError(t, err)
+	data, err := store.Get("customer")
+	require.NoError(t, err)
+	require.Nil(t, data)
+}